@@ -0,0 +1,126 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	adaptiveInitialLimit = 10
+	adaptiveMinLimit     = 1
+	adaptiveMaxLimit     = 1000
+
+	// latency above baseline*adaptiveTolerance is considered a sign of overload
+	adaptiveTolerance = 2.0
+
+	// how much of the limit is kept when backing off
+	adaptiveBackoff = 0.9
+
+	// how far the baseline drifts towards each slower latency,
+	// so it follows lasting changes in the server's normal latency instead of sticking to an all-time minimum
+	adaptiveBaselineDecay = 0.01
+)
+
+// AdaptiveLimit is the concurrency limit maintained by AdaptiveConcurrencyHTTPClient
+type AdaptiveLimit struct {
+	mu       sync.Mutex
+	limit    float64
+	inflight int
+	baseline time.Duration
+
+	// released is closed (and replaced) every time a request completes, waking up waiting requests
+	released chan struct{}
+}
+
+// Limit returns the current concurrency limit
+func (l *AdaptiveLimit) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
+
+// Inflight returns the number of requests currently in flight
+func (l *AdaptiveLimit) Inflight() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.inflight
+}
+
+func (l *AdaptiveLimit) acquire(req *http.Request) error {
+	l.mu.Lock()
+	for l.inflight >= int(l.limit) {
+		ch := l.released
+		l.mu.Unlock()
+
+		select {
+		case <-ch:
+		case <-req.Context().Done():
+			return req.Context().Err()
+		}
+
+		l.mu.Lock()
+	}
+	l.inflight++
+	l.mu.Unlock()
+
+	return nil
+}
+
+// release records the outcome of a request and adjusts the limit (AIMD):
+// additive increase while latency stays near the baseline, multiplicative decrease when it rises or the request fails
+func (l *AdaptiveLimit) release(latency time.Duration, failed bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inflight--
+
+	if !failed {
+		if l.baseline == 0 || latency < l.baseline {
+			l.baseline = latency
+		} else {
+			l.baseline += time.Duration(float64(latency-l.baseline) * adaptiveBaselineDecay)
+		}
+	}
+
+	if failed || float64(latency) > float64(l.baseline)*adaptiveTolerance {
+		l.limit *= adaptiveBackoff
+	} else {
+		// grows by roughly 1 for every `limit` successful requests
+		l.limit += 1 / l.limit
+	}
+
+	if l.limit < adaptiveMinLimit {
+		l.limit = adaptiveMinLimit
+	}
+	if l.limit > adaptiveMaxLimit {
+		l.limit = adaptiveMaxLimit
+	}
+
+	close(l.released)
+	l.released = make(chan struct{})
+}
+
+// AdaptiveConcurrencyHTTPClient limits the number of concurrent requests to the given HTTPClient,
+// adjusting the limit based on observed latency to maximize throughput without overloading the server
+// Requests over the limit wait for a slot, or until their context is done
+func AdaptiveConcurrencyHTTPClient(c HTTPClient) (HTTPClient, *AdaptiveLimit) {
+	l := &AdaptiveLimit{
+		limit:    adaptiveInitialLimit,
+		released: make(chan struct{}),
+	}
+
+	return &MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			if err := l.acquire(req); err != nil {
+				return nil, err
+			}
+
+			start := time.Now()
+			res, err := c.Do(req)
+			l.release(time.Since(start), err != nil || (res != nil && res.StatusCode >= 500))
+
+			return res, err
+		},
+	}, l
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// observe runs a request with the given latency through the limit
+func observe(t *testing.T, l *AdaptiveLimit, latency time.Duration) {
+	t.Helper()
+
+	req, _ := http.NewRequest("GET", "http://example.com", nil)
+	if err := l.acquire(req); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	l.release(latency, false)
+}
+
+func TestAdaptiveLimitGrowsWhenStable(t *testing.T) {
+	_, l := AdaptiveConcurrencyHTTPClient(nil)
+
+	for i := 0; i < 200; i++ {
+		observe(t, l, 10*time.Millisecond)
+	}
+
+	if l.Limit() <= adaptiveInitialLimit {
+		t.Fatalf("expected the limit to grow beyond %d, got %d", adaptiveInitialLimit, l.Limit())
+	}
+}
+
+func TestAdaptiveLimitShrinksUnderLatency(t *testing.T) {
+	_, l := AdaptiveConcurrencyHTTPClient(nil)
+
+	observe(t, l, 10*time.Millisecond)
+	for i := 0; i < 10; i++ {
+		observe(t, l, 50*time.Millisecond)
+	}
+
+	if l.Limit() >= adaptiveInitialLimit {
+		t.Fatalf("expected the limit to shrink below %d, got %d", adaptiveInitialLimit, l.Limit())
+	}
+}
+
+func TestAdaptiveLimitBaselineDecays(t *testing.T) {
+	_, l := AdaptiveConcurrencyHTTPClient(nil)
+
+	// a single unusually fast request shouldn't hold the limit down forever
+	observe(t, l, time.Millisecond)
+	for i := 0; i < 100; i++ {
+		observe(t, l, 10*time.Millisecond)
+	}
+	before := l.Limit()
+
+	for i := 0; i < 200; i++ {
+		observe(t, l, 10*time.Millisecond)
+	}
+
+	if l.Limit() <= before {
+		t.Fatalf("expected the limit to grow again once the baseline caught up, stayed at %d", l.Limit())
+	}
+}
+
+func TestAdaptiveConcurrencyHTTPClient(t *testing.T) {
+	c, l := AdaptiveConcurrencyHTTPClient(&MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			return stringResponse(req, 200, "ok"), nil
+		},
+	})
+
+	req, _ := http.NewRequest("GET", "http://example.com", nil)
+	if _, err := c.Do(req); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if l.Inflight() != 0 {
+		t.Fatalf("expected no requests in flight, got %d", l.Inflight())
+	}
+}
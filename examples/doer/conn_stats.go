@@ -0,0 +1,92 @@
+package main
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ConnStats aggregates connection level statistics collected by ConnStatsHTTPClient
+type ConnStats struct {
+	reused       int64
+	created      int64
+	dnsLookups   int64
+	dnsTime      int64
+	tlsHandshake int64
+	tlsTime      int64
+}
+
+// ReusedConns returns the number of requests sent over a reused (pooled) connection
+func (s *ConnStats) ReusedConns() int64 { return atomic.LoadInt64(&s.reused) }
+
+// NewConns returns the number of requests which required a new connection
+func (s *ConnStats) NewConns() int64 { return atomic.LoadInt64(&s.created) }
+
+// DNSLookups returns the number of DNS lookups performed
+func (s *ConnStats) DNSLookups() int64 { return atomic.LoadInt64(&s.dnsLookups) }
+
+// DNSTime returns the total time spent on DNS lookups
+func (s *ConnStats) DNSTime() time.Duration { return time.Duration(atomic.LoadInt64(&s.dnsTime)) }
+
+// TLSHandshakes returns the number of TLS handshakes performed
+func (s *ConnStats) TLSHandshakes() int64 { return atomic.LoadInt64(&s.tlsHandshake) }
+
+// TLSTime returns the total time spent on TLS handshakes
+func (s *ConnStats) TLSTime() time.Duration { return time.Duration(atomic.LoadInt64(&s.tlsTime)) }
+
+// ConnStatsHTTPClient records whether each request reused a connection, along with DNS and TLS handshake times
+// It relies on httptrace, so it's only meaningful in front of a real http.Client
+// Any ClientTrace already in the request context keeps receiving its events as well
+func ConnStatsHTTPClient(c HTTPClient) (HTTPClient, *ConnStats) {
+	s := &ConnStats{}
+
+	return &MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			// the transport fires trace callbacks from its own dialing goroutines, which may outlive the request
+			var mu sync.Mutex
+			var dnsStart, tlsStart time.Time
+
+			since := func(start *time.Time) time.Duration {
+				mu.Lock()
+				defer mu.Unlock()
+				return time.Since(*start)
+			}
+
+			trace := &httptrace.ClientTrace{
+				GotConn: func(info httptrace.GotConnInfo) {
+					if info.Reused {
+						atomic.AddInt64(&s.reused, 1)
+					} else {
+						atomic.AddInt64(&s.created, 1)
+					}
+				},
+				DNSStart: func(httptrace.DNSStartInfo) {
+					mu.Lock()
+					dnsStart = time.Now()
+					mu.Unlock()
+				},
+				DNSDone: func(httptrace.DNSDoneInfo) {
+					atomic.AddInt64(&s.dnsLookups, 1)
+					atomic.AddInt64(&s.dnsTime, int64(since(&dnsStart)))
+				},
+				TLSHandshakeStart: func() {
+					mu.Lock()
+					tlsStart = time.Now()
+					mu.Unlock()
+				},
+				TLSHandshakeDone: func(tls.ConnectionState, error) {
+					atomic.AddInt64(&s.tlsHandshake, 1)
+					atomic.AddInt64(&s.tlsTime, int64(since(&tlsStart)))
+				},
+			}
+
+			// WithClientTrace composes with any trace already present rather than replacing it
+			ctx := httptrace.WithClientTrace(req.Context(), trace)
+
+			return c.Do(req.WithContext(ctx))
+		},
+	}, s
+}
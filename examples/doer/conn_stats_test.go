@@ -0,0 +1,53 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"sync/atomic"
+	"testing"
+)
+
+func TestConnStatsHTTPClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+
+	c, stats := ConnStatsHTTPClient(srv.Client())
+
+	var gotConns int64
+	trace := &httptrace.ClientTrace{
+		GotConn: func(httptrace.GotConnInfo) {
+			atomic.AddInt64(&gotConns, 1)
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+		res, err := c.Do(req)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		// drain the body so the connection goes back to the pool
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+	}
+
+	if got := stats.NewConns(); got != 1 {
+		t.Fatalf("expected 1 new connection, got %d", got)
+	}
+	if got := stats.ReusedConns(); got != 1 {
+		t.Fatalf("expected 1 reused connection, got %d", got)
+	}
+	if got := atomic.LoadInt64(&gotConns); got != 2 {
+		t.Fatalf("expected the existing trace to see 2 connections, got %d", got)
+	}
+}
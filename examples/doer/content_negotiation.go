@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// acceptHeader builds an Accept header from media types in order of preference, e.g `a, b;q=0.9, c;q=0.8`
+func acceptHeader(types []string) string {
+	parts := make([]string, len(types))
+	for i, t := range types {
+		q := 1.0 - 0.1*float64(i)
+		if q < 0.1 {
+			q = 0.1
+		}
+
+		if i == 0 {
+			parts[i] = t
+			continue
+		}
+		parts[i] = fmt.Sprintf("%s;q=%.1f", t, q)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ContentNegotiationHTTPClient sets an Accept header listing the given media types in order of preference
+// If the server responds with 406 Not Acceptable, the request is retried without the most preferred remaining type
+func ContentNegotiationHTTPClient(c HTTPClient, accept ...string) HTTPClient {
+	return &MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			var res *http.Response
+
+			for i := range accept {
+				// don't modify the caller's request, a fallback isn't a retry so the attempt stays the same
+				r, err := replayRequest(req.Context(), req, i > 0)
+				if err != nil {
+					return nil, err
+				}
+
+				if r.Header == nil {
+					r.Header = http.Header{}
+				}
+				r.Header.Set("Accept", acceptHeader(accept[i:]))
+
+				res, err = c.Do(r)
+				if err != nil {
+					return nil, err
+				}
+
+				if res.StatusCode != http.StatusNotAcceptable || i == len(accept)-1 {
+					return res, nil
+				}
+
+				// try again with the next preference
+				if res.Body != nil {
+					res.Body.Close()
+				}
+			}
+
+			// no preferences given
+			return c.Do(req)
+		},
+	}
+}
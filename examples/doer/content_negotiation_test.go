@@ -0,0 +1,57 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestContentNegotiationHTTPClient(t *testing.T) {
+	type call struct {
+		accept  string
+		attempt int
+		body    string
+	}
+	calls := []call{}
+
+	c := ContentNegotiationHTTPClient(&MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			bs, _ := io.ReadAll(req.Body)
+			accept := req.Header.Get("Accept")
+			calls = append(calls, call{accept, Attempt(req.Context()), string(bs)})
+
+			// the server only speaks XML
+			if !strings.HasPrefix(accept, "application/xml") {
+				return stringResponse(req, http.StatusNotAcceptable, "not acceptable"), nil
+			}
+			return stringResponse(req, 200, "<ok/>"), nil
+		},
+	}, "application/json", "application/xml")
+
+	req, _ := http.NewRequest("POST", "http://example.com", strings.NewReader("payload"))
+	res, err := c.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if res.StatusCode != 200 {
+		t.Fatalf("expected the second preference to be accepted, got %d", res.StatusCode)
+	}
+
+	expected := []call{
+		{"application/json, application/xml;q=0.9", 1, "payload"},
+		{"application/xml", 1, "payload"},
+	}
+	if len(calls) != len(expected) {
+		t.Fatalf("expected %d calls, got %v", len(expected), calls)
+	}
+	for i := range expected {
+		if calls[i] != expected[i] {
+			t.Fatalf("call %d: expected %+v, got %+v", i, expected[i], calls[i])
+		}
+	}
+
+	if req.Header.Get("Accept") != "" {
+		t.Fatal("expected the caller's request to be left untouched")
+	}
+}
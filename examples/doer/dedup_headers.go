@@ -0,0 +1,38 @@
+package main
+
+import (
+	"net/http"
+)
+
+// DedupHeadersHTTPClient collapses each of the given single-valued headers (e.g User-Agent or Authorization)
+// to a single value before the request is sent, keeping the last value when keepLast is set or the first otherwise
+// This undoes duplicates added by several wrappers in a composed stack,
+// so wrap the real client with it directly, that way it runs after all other wrappers right before the request is sent
+// Other headers, including multi-valued ones, are left as they are, as is the caller's request
+func DedupHeadersHTTPClient(c HTTPClient, keepLast bool, single ...string) HTTPClient {
+	return &MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			r := req
+
+			for _, name := range single {
+				vs := req.Header.Values(name)
+				if len(vs) < 2 {
+					continue
+				}
+
+				// only clone once there's something to change
+				if r == req {
+					r = req.Clone(req.Context())
+				}
+
+				v := vs[0]
+				if keepLast {
+					v = vs[len(vs)-1]
+				}
+				r.Header.Set(name, v)
+			}
+
+			return c.Do(r)
+		},
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestDedupHeadersHTTPClient(t *testing.T) {
+	for _, tc := range []struct {
+		keepLast bool
+		want     string
+	}{
+		{keepLast: false, want: "first"},
+		{keepLast: true, want: "last"},
+	} {
+		var got http.Header
+		c := DedupHeadersHTTPClient(&MockHTTPClient{
+			DoFn: func(req *http.Request) (*http.Response, error) {
+				got = req.Header
+				return stringResponse(req, http.StatusOK, ""), nil
+			},
+		}, tc.keepLast, "User-Agent")
+
+		req, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		req.Header.Add("User-Agent", "first")
+		req.Header.Add("User-Agent", "last")
+		req.Header.Add("Accept", "text/html")
+		req.Header.Add("Accept", "application/json")
+
+		if _, err := c.Do(req); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		if vs := got.Values("User-Agent"); !reflect.DeepEqual(vs, []string{tc.want}) {
+			t.Fatalf("expected User-Agent to be collapsed to %q with keepLast %v, got %q", tc.want, tc.keepLast, vs)
+		}
+		if vs, want := got.Values("Accept"), []string{"text/html", "application/json"}; !reflect.DeepEqual(vs, want) {
+			t.Fatalf("expected multi-valued Accept to be kept as %q, got %q", want, vs)
+		}
+		if vs := req.Header.Values("User-Agent"); len(vs) != 2 {
+			t.Fatalf("expected the caller's request to be left untouched, got %q", vs)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ExtractJSON sends the request using the given HTTPClient and extracts a single value from the JSON response body
+// The value is located using a dotted path, where numeric segments index into arrays, e.g `data.items.0.id`
+// An empty path returns the whole document
+func ExtractJSON(c HTTPClient, req *http.Request, path string) (any, error) {
+	res, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var v any
+	if err := json.NewDecoder(res.Body).Decode(&v); err != nil {
+		return nil, fmt.Errorf("failed to decode json response: %w", err)
+	}
+
+	if path == "" {
+		return v, nil
+	}
+
+	segs := strings.Split(path, ".")
+	for i, seg := range segs {
+		// used to point out exactly where the path broke off
+		at := strings.Join(segs[:i+1], ".")
+
+		switch node := v.(type) {
+		case map[string]any:
+			next, ok := node[seg]
+			if !ok {
+				return nil, fmt.Errorf("json path %q: key %q not found", path, at)
+			}
+			v = next
+
+		case []any:
+			idx, err := strconv.Atoi(seg)
+			if err != nil {
+				return nil, fmt.Errorf("json path %q: %q is not an array index", path, at)
+			}
+			if idx < 0 || idx >= len(node) {
+				return nil, fmt.Errorf("json path %q: index %q out of range (length %d)", path, at, len(node))
+			}
+			v = node[idx]
+
+		default:
+			return nil, fmt.Errorf("json path %q: %q not found, parent is not an object or array", path, at)
+		}
+	}
+
+	return v, nil
+}
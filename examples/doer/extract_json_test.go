@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestExtractJSON(t *testing.T) {
+	c := &MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			return stringResponse(req, 200, `{"data": {"items": [{"id": "a"}, {"id": "b"}]}}`), nil
+		},
+	}
+
+	tcs := []struct {
+		path        string
+		expected    any
+		expectedErr string
+	}{
+		{"data.items.1.id", "b", ""},
+		{"data.missing", nil, `key "data.missing" not found`},
+		{"data.items.5", nil, `index "data.items.5" out of range`},
+		{"data.items.x", nil, `"data.items.x" is not an array index`},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.path, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "http://example.com", nil)
+			v, err := ExtractJSON(c, req, tc.path)
+
+			if tc.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if v != tc.expected {
+				t.Fatalf("expected %v, got %v", tc.expected, v)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// Gate holds back requests until they are released, which allows testing concurrent behavior deterministically
+type Gate struct {
+	mu      sync.Mutex
+	credits int
+	waiters []chan struct{}
+}
+
+// Release admits the next n requests, in the order they arrived
+// Any releases not used up by requests currently waiting are saved for future requests
+func (g *Gate) Release(n int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for n > 0 && len(g.waiters) > 0 {
+		close(g.waiters[0])
+		g.waiters = g.waiters[1:]
+		n--
+	}
+
+	g.credits += n
+}
+
+// Waiting returns the number of requests currently held back
+func (g *Gate) Waiting() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.waiters)
+}
+
+// wait blocks until the request is admitted, or ctx is done
+func (g *Gate) wait(ctx context.Context) error {
+	g.mu.Lock()
+	if g.credits > 0 {
+		g.credits--
+		g.mu.Unlock()
+		return nil
+	}
+
+	ch := make(chan struct{})
+	g.waiters = append(g.waiters, ch)
+	g.mu.Unlock()
+
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for i, w := range g.waiters {
+		if w == ch {
+			g.waiters = append(g.waiters[:i], g.waiters[i+1:]...)
+			return ctx.Err()
+		}
+	}
+
+	// we were released in the meantime, hand the release over to someone else
+	if len(g.waiters) > 0 {
+		close(g.waiters[0])
+		g.waiters = g.waiters[1:]
+	} else {
+		g.credits++
+	}
+	return ctx.Err()
+}
+
+// GateHTTPClient holds back every request until it's admitted by the returned Gate
+func GateHTTPClient(c HTTPClient) (HTTPClient, *Gate) {
+	g := &Gate{}
+
+	return &MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			if err := g.wait(req.Context()); err != nil {
+				return nil, err
+			}
+			return c.Do(req)
+		},
+	}, g
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestGateHTTPClient(t *testing.T) {
+	completed := make(chan int, 3)
+	c, g := GateHTTPClient(&MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			i, _ := strconv.Atoi(req.URL.Query().Get("i"))
+			completed <- i
+			return stringResponse(req, 200, "ok"), nil
+		},
+	})
+
+	// queue up requests one at a time so their arrival order is known
+	for i := 0; i < 3; i++ {
+		go func(i int) {
+			req, _ := http.NewRequest("GET", "http://example.com?i="+strconv.Itoa(i), nil)
+			c.Do(req)
+		}(i)
+
+		waitFor(t, func() bool { return g.Waiting() == i+1 })
+	}
+
+	select {
+	case i := <-completed:
+		t.Fatalf("expected requests to be held back, request %d went through", i)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	// the first two to arrive are admitted, though they may race each other from there
+	g.Release(2)
+	admitted := map[int]bool{}
+	for len(admitted) < 2 {
+		select {
+		case i := <-completed:
+			admitted[i] = true
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for released requests")
+		}
+	}
+	if !admitted[0] || !admitted[1] {
+		t.Fatalf("expected requests 0 and 1 to be admitted, got %v", admitted)
+	}
+
+	select {
+	case i := <-completed:
+		t.Fatalf("expected exactly two requests to be admitted, request %d went through", i)
+	case <-time.After(20 * time.Millisecond):
+	}
+	if g.Waiting() != 1 {
+		t.Fatalf("expected 1 request to still be waiting, got %d", g.Waiting())
+	}
+
+	g.Release(1)
+	if i := <-completed; i != 2 {
+		t.Fatalf("expected request 2 to be admitted, got %d", i)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HARLog holds request/response pairs captured by HARRecorderHTTPClient
+type HARLog struct {
+	mu      sync.Mutex
+	entries []harEntry
+}
+
+// Write serializes the captured entries to w in HAR (HTTP Archive) 1.2 JSON format
+func (l *HARLog) Write(w io.Writer) error {
+	l.mu.Lock()
+	entries := make([]harEntry, len(l.entries))
+	copy(entries, l.entries)
+	l.mu.Unlock()
+
+	doc := harDocument{
+		Log: harLogBody{
+			Version: "1.2",
+			Creator: harCreator{Name: "stubby-mcmockerface", Version: "1.0"},
+			Entries: entries,
+		},
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+func (l *HARLog) add(e harEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, e)
+}
+
+// HARRecorderHTTPClient records all traffic going through the given HTTPClient into a HARLog
+// Request and response bodies are buffered so they can be recorded without being consumed
+func HARRecorderHTTPClient(c HTTPClient) (HTTPClient, *HARLog) {
+	l := &HARLog{}
+
+	rc := &MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			// capture the request body, then put it back for the wrapped client
+			var reqBody []byte
+			if req.Body != nil {
+				bs, err := io.ReadAll(req.Body)
+				req.Body.Close()
+				if err != nil {
+					return nil, err
+				}
+				reqBody = bs
+				req.Body = io.NopCloser(bytes.NewReader(bs))
+			}
+
+			start := time.Now()
+
+			res, err := c.Do(req)
+			if err != nil {
+				return nil, err
+			}
+
+			// same for the response body, so the caller can still read it
+			var resBody []byte
+			if res.Body != nil {
+				bs, err := io.ReadAll(res.Body)
+				res.Body.Close()
+				if err != nil {
+					return nil, err
+				}
+				resBody = bs
+				res.Body = io.NopCloser(bytes.NewReader(bs))
+			}
+
+			elapsed := time.Since(start)
+
+			l.add(newHAREntry(req, reqBody, res, resBody, start, elapsed))
+
+			return res, nil
+		},
+	}
+
+	return rc, l
+}
+
+type harDocument struct {
+	Log harLogBody `json:"log"`
+}
+
+type harLogBody struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+}
+
+type harRequest struct {
+	Method      string       `json:"method"`
+	URL         string       `json:"url"`
+	HTTPVersion string       `json:"httpVersion"`
+	Headers     []harNameVal `json:"headers"`
+	QueryString []harNameVal `json:"queryString"`
+	PostData    *harPostData `json:"postData,omitempty"`
+	HeadersSize int          `json:"headersSize"`
+	BodySize    int          `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int          `json:"status"`
+	StatusText  string       `json:"statusText"`
+	HTTPVersion string       `json:"httpVersion"`
+	Headers     []harNameVal `json:"headers"`
+	Content     harContent   `json:"content"`
+	RedirectURL string       `json:"redirectURL"`
+	HeadersSize int          `json:"headersSize"`
+	BodySize    int          `json:"bodySize"`
+}
+
+type harNameVal struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+func newHAREntry(req *http.Request, reqBody []byte, res *http.Response, resBody []byte, start time.Time, elapsed time.Duration) harEntry {
+	ms := float64(elapsed) / float64(time.Millisecond)
+
+	e := harEntry{
+		StartedDateTime: start.Format(time.RFC3339Nano),
+		Time:            ms,
+		Request: harRequest{
+			Method:      req.Method,
+			URL:         req.URL.String(),
+			HTTPVersion: protoOrDefault(req.Proto),
+			Headers:     harHeaders(req.Header),
+			QueryString: []harNameVal{},
+			HeadersSize: -1,
+			BodySize:    len(reqBody),
+		},
+		Response: harResponse{
+			Status:      res.StatusCode,
+			StatusText:  http.StatusText(res.StatusCode),
+			HTTPVersion: protoOrDefault(res.Proto),
+			Headers:     harHeaders(res.Header),
+			Content: harContent{
+				Size:     len(resBody),
+				MimeType: res.Header.Get("Content-Type"),
+				Text:     string(resBody),
+			},
+			RedirectURL: res.Header.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    len(resBody),
+		},
+		// we only measure the full round trip, so attribute it all to waiting
+		Timings: harTimings{Send: 0, Wait: ms, Receive: 0},
+	}
+
+	for k, vs := range req.URL.Query() {
+		for _, v := range vs {
+			e.Request.QueryString = append(e.Request.QueryString, harNameVal{Name: k, Value: v})
+		}
+	}
+
+	if reqBody != nil {
+		e.Request.PostData = &harPostData{
+			MimeType: req.Header.Get("Content-Type"),
+			Text:     string(reqBody),
+		}
+	}
+
+	return e
+}
+
+func harHeaders(h http.Header) []harNameVal {
+	nvs := []harNameVal{}
+	for k, vs := range h {
+		for _, v := range vs {
+			nvs = append(nvs, harNameVal{Name: k, Value: v})
+		}
+	}
+	return nvs
+}
+
+func protoOrDefault(proto string) string {
+	if proto == "" {
+		return "HTTP/1.1"
+	}
+	return proto
+}
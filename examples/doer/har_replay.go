@@ -0,0 +1,130 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// ErrNoRecordedResponse is returned when replaying a request which doesn't match any recorded entry
+var ErrNoRecordedResponse = errors.New("no recorded response matches request")
+
+// MatchField is a part of a request used to match it against recorded requests
+type MatchField int
+
+const (
+	MatchMethod MatchField = iota
+	MatchURL
+	MatchBody
+)
+
+// ReadHARLog reads a HAR log previously written by HARLog.Write, e.g a cassette stored on disk
+func ReadHARLog(r io.Reader) (*HARLog, error) {
+	var doc harDocument
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+	return &HARLog{entries: doc.Log.Entries}, nil
+}
+
+// HARReplayHTTPClient replays responses recorded in the given HARLog without sending any requests
+// Requests are matched to recorded ones by a fingerprint of the given fields (method, URL and body by default)
+// rather than by order, so replay works for reordered or concurrent requests
+// Identical requests recorded several times are replayed in recorded order, repeating the last one once exhausted
+func HARReplayHTTPClient(l *HARLog, matchOn ...MatchField) HTTPClient {
+	if len(matchOn) == 0 {
+		matchOn = []MatchField{MatchMethod, MatchURL, MatchBody}
+	}
+
+	l.mu.Lock()
+	recorded := map[string][]harEntry{}
+	for _, e := range l.entries {
+		var body []byte
+		if e.Request.PostData != nil {
+			body = []byte(e.Request.PostData.Text)
+		}
+
+		fp, err := fingerprint(matchOn, e.Request.Method, e.Request.URL, body)
+		if err != nil {
+			continue
+		}
+		recorded[fp] = append(recorded[fp], e)
+	}
+	l.mu.Unlock()
+
+	var mu sync.Mutex
+
+	return &MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			var body []byte
+			if req.Body != nil {
+				bs, err := io.ReadAll(req.Body)
+				req.Body.Close()
+				if err != nil {
+					return nil, err
+				}
+				body = bs
+			}
+
+			fp, err := fingerprint(matchOn, req.Method, req.URL.String(), body)
+			if err != nil {
+				return nil, err
+			}
+
+			mu.Lock()
+			es := recorded[fp]
+			if len(es) == 0 {
+				mu.Unlock()
+				return nil, fmt.Errorf("%w: %s %s", ErrNoRecordedResponse, req.Method, req.URL)
+			}
+
+			e := es[0]
+			if len(es) > 1 {
+				recorded[fp] = es[1:]
+			}
+			mu.Unlock()
+
+			return harResponseToHTTP(req, e.Response), nil
+		},
+	}
+}
+
+// fingerprint identifies a request by the given fields
+func fingerprint(matchOn []MatchField, method, rawURL string, body []byte) (string, error) {
+	parts := []string{}
+
+	for _, f := range matchOn {
+		switch f {
+		case MatchMethod:
+			parts = append(parts, method)
+
+		case MatchURL:
+			u, err := url.Parse(rawURL)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, NormalizeURL(u).String())
+
+		case MatchBody:
+			sum := sha256.Sum256(body)
+			parts = append(parts, hex.EncodeToString(sum[:]))
+		}
+	}
+
+	return strings.Join(parts, " "), nil
+}
+
+func harResponseToHTTP(req *http.Request, hr harResponse) *http.Response {
+	res := stringResponse(req, hr.Status, hr.Content.Text)
+	for _, h := range hr.Headers {
+		res.Header.Add(h.Name, h.Value)
+	}
+	return res
+}
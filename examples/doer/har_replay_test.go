@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestHARReplayHTTPClient(t *testing.T) {
+	rec, l := HARRecorderHTTPClient(&MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			body := req.Method + " " + req.URL.Path
+			if req.Body != nil {
+				bs, _ := io.ReadAll(req.Body)
+				body += " " + string(bs)
+			}
+			return stringResponse(req, 200, body), nil
+		},
+	})
+
+	type call struct{ method, url, body string }
+	calls := []call{
+		{"GET", "http://example.com/a", ""},
+		{"GET", "http://example.com/b", ""},
+		{"POST", "http://example.com/c", "payload"},
+	}
+
+	newRequest := func(c call) *http.Request {
+		var body io.Reader
+		if c.body != "" {
+			body = strings.NewReader(c.body)
+		}
+		req, _ := http.NewRequest(c.method, c.url, body)
+		return req
+	}
+
+	for _, c := range calls {
+		res, err := rec.Do(newRequest(c))
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		io.ReadAll(res.Body)
+		res.Body.Close()
+	}
+
+	// round trip the cassette through its on-disk format
+	var buf bytes.Buffer
+	if err := l.Write(&buf); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	replayLog, err := ReadHARLog(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	replay := HARReplayHTTPClient(replayLog)
+
+	// replay out of order
+	for _, i := range []int{2, 0, 1} {
+		res, err := replay.Do(newRequest(calls[i]))
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		expected := calls[i].method + " " + strings.TrimPrefix(calls[i].url, "http://example.com")
+		if calls[i].body != "" {
+			expected += " " + calls[i].body
+		}
+		if bs, _ := io.ReadAll(res.Body); string(bs) != expected {
+			t.Fatalf("expected %q, got %q", expected, bs)
+		}
+	}
+
+	// same url, different body
+	if _, err := replay.Do(newRequest(call{"POST", "http://example.com/c", "other"})); !errors.Is(err, ErrNoRecordedResponse) {
+		t.Fatalf("expected ErrNoRecordedResponse, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestHARRecorderHTTPClient(t *testing.T) {
+	c, l := HARRecorderHTTPClient(&MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			bs, err := io.ReadAll(req.Body)
+			if err != nil {
+				return nil, err
+			}
+			return stringResponse(req, 201, "got "+string(bs)), nil
+		},
+	})
+
+	req, err := http.NewRequest("POST", "http://example.com/items?a=1", strings.NewReader("item"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// recording shouldn't consume the live response body
+	bs, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(bs) != "got item" {
+		t.Fatalf("expected live body %q, got %q", "got item", bs)
+	}
+
+	var buf bytes.Buffer
+	if err := l.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		Log struct {
+			Version string `json:"version"`
+			Entries []struct {
+				Request struct {
+					Method   string `json:"method"`
+					URL      string `json:"url"`
+					PostData struct {
+						Text string `json:"text"`
+					} `json:"postData"`
+				} `json:"request"`
+				Response struct {
+					Status  int `json:"status"`
+					Content struct {
+						Text string `json:"text"`
+					} `json:"content"`
+				} `json:"response"`
+			} `json:"entries"`
+		} `json:"log"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.Log.Version != "1.2" {
+		t.Errorf("expected HAR version 1.2, got %q", doc.Log.Version)
+	}
+
+	if len(doc.Log.Entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(doc.Log.Entries))
+	}
+
+	e := doc.Log.Entries[0]
+	if e.Request.Method != "POST" || e.Request.URL != "http://example.com/items?a=1" {
+		t.Errorf("unexpected request %s %s", e.Request.Method, e.Request.URL)
+	}
+	if e.Request.PostData.Text != "item" {
+		t.Errorf("unexpected request body %q", e.Request.PostData.Text)
+	}
+	if e.Response.Status != 201 || e.Response.Content.Text != "got item" {
+		t.Errorf("unexpected response %d %q", e.Response.Status, e.Response.Content.Text)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrHostBackoff is returned when a request is refused because its host is backing off after failures
+var ErrHostBackoff = errors.New("host is backing off after repeated failures")
+
+const (
+	hostBackoffBase = 100 * time.Millisecond
+	hostBackoffMax  = time.Minute
+)
+
+type hostBackoffState struct {
+	failures int
+	until    time.Time
+}
+
+// HostBackoffHTTPClient tracks consecutive failures (errors or 5xx responses) per host
+// After a failure, requests to that host are refused with ErrHostBackoff until its backoff elapses
+// The backoff doubles with each consecutive failure and is reset on success
+// Other hosts are not affected
+func HostBackoffHTTPClient(c HTTPClient) HTTPClient {
+	var mu sync.Mutex
+	hosts := map[string]*hostBackoffState{}
+
+	return &MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			host := req.URL.Host
+
+			mu.Lock()
+			s, ok := hosts[host]
+			if ok && time.Now().Before(s.until) {
+				mu.Unlock()
+				return nil, ErrHostBackoff
+			}
+			mu.Unlock()
+
+			res, err := c.Do(req)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			// success, forget about any previous failures
+			if err == nil && res.StatusCode < 500 {
+				delete(hosts, host)
+				return res, nil
+			}
+
+			s, ok = hosts[host]
+			if !ok {
+				s = &hostBackoffState{}
+				hosts[host] = s
+			}
+			s.failures++
+
+			backoff := hostBackoffMax
+			if s.failures < 32 {
+				backoff = hostBackoffBase << uint(s.failures-1)
+			}
+			if backoff > hostBackoffMax {
+				backoff = hostBackoffMax
+			}
+			s.until = time.Now().Add(backoff)
+
+			return res, err
+		},
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestHostBackoffHTTPClient(t *testing.T) {
+	calls := map[string]int{}
+	c := HostBackoffHTTPClient(&MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			calls[req.URL.Host]++
+			if req.URL.Host == "bad.example.com" {
+				return stringResponse(req, 503, "unavailable"), nil
+			}
+			return stringResponse(req, 200, "ok"), nil
+		},
+	})
+
+	do := func(url string) (*http.Response, error) {
+		req, _ := http.NewRequest("GET", url, nil)
+		return c.Do(req)
+	}
+
+	if res, err := do("http://bad.example.com"); err != nil || res.StatusCode != 503 {
+		t.Fatalf("expected the first failure to be passed through, got %v, %v", res, err)
+	}
+
+	if _, err := do("http://bad.example.com"); !errors.Is(err, ErrHostBackoff) {
+		t.Fatalf("expected ErrHostBackoff, got %v", err)
+	}
+	if calls["bad.example.com"] != 1 {
+		t.Fatalf("expected a backing off host not to be called, got %d calls", calls["bad.example.com"])
+	}
+
+	for i := 0; i < 3; i++ {
+		if res, err := do("http://good.example.com"); err != nil || res.StatusCode != 200 {
+			t.Fatalf("expected other hosts to be unaffected, got %v, %v", res, err)
+		}
+	}
+	if calls["good.example.com"] != 3 {
+		t.Fatalf("expected 3 calls to the healthy host, got %d", calls["good.example.com"])
+	}
+}
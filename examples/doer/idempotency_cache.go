@@ -0,0 +1,80 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+type idempotentCall struct {
+	done chan struct{}
+	cr   *cachedResponse
+	err  error
+}
+
+// IdempotencyCacheHTTPClient only sends the first request carrying a given idempotency key (in the given header)
+// Any other request with the same key within ttl receives a replay of the first request's response instead
+// Requests without the header are sent as usual, and failed requests aren't remembered
+// Requests made with a NoCache context are always sent, and their response replaces any remembered one
+func IdempotencyCacheHTTPClient(c HTTPClient, header string, ttl time.Duration) HTTPClient {
+	var mu sync.Mutex
+	calls := map[string]*idempotentCall{}
+
+	return &MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			key := req.Header.Get(header)
+			if key == "" {
+				return c.Do(req)
+			}
+
+			mu.Lock()
+			if call, ok := calls[key]; ok && !isNoCache(req.Context()) {
+				mu.Unlock()
+
+				// the first request may still be in flight
+				<-call.done
+				if call.err == nil && time.Now().Before(call.cr.expires) {
+					return call.cr.response(req), nil
+				}
+
+				// the original request failed or expired, so this one goes through
+				return c.Do(req)
+			}
+
+			call := &idempotentCall{done: make(chan struct{})}
+			calls[key] = call
+			mu.Unlock()
+
+			defer close(call.done)
+
+			res, err := c.Do(req)
+			if err == nil {
+				call.cr, err = newCachedResponse(res, ttl)
+			}
+
+			if err != nil {
+				call.err = err
+
+				// a NoCache request may have taken over the key in the meantime
+				mu.Lock()
+				if calls[key] == call {
+					delete(calls, key)
+				}
+				mu.Unlock()
+
+				return nil, err
+			}
+
+			// evict the entry once it expires
+			time.AfterFunc(ttl, func() {
+				mu.Lock()
+				defer mu.Unlock()
+				if calls[key] == call {
+					delete(calls, key)
+				}
+			})
+
+			return res, nil
+		},
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyCacheHTTPClient(t *testing.T) {
+	calls := 0
+	c := IdempotencyCacheHTTPClient(&MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			calls++
+			return stringResponse(req, 201, "created"), nil
+		},
+	}, "Idempotency-Key", time.Hour)
+
+	do := func(ctx context.Context, key string) {
+		req, _ := http.NewRequestWithContext(ctx, "POST", "http://example.com/orders", nil)
+		req.Header.Set("Idempotency-Key", key)
+
+		res, err := c.Do(req)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if bs, _ := io.ReadAll(res.Body); res.StatusCode != 201 || string(bs) != "created" {
+			t.Fatalf("unexpected response %d %q", res.StatusCode, bs)
+		}
+	}
+
+	do(context.Background(), "abc")
+	do(context.Background(), "abc")
+	if calls != 1 {
+		t.Fatalf("expected requests with the same key to make 1 call, got %d", calls)
+	}
+
+	do(context.Background(), "def")
+	if calls != 2 {
+		t.Fatalf("expected a different key to be sent, got %d calls", calls)
+	}
+
+	do(NoCache(context.Background()), "abc")
+	if calls != 3 {
+		t.Fatalf("expected NoCache to bypass the cache, got %d calls", calls)
+	}
+}
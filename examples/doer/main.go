@@ -176,8 +176,14 @@ func RetryHTTPClient(c HTTPClient, retries int) HTTPClient {
 
 			// try `retries` times
 			for i := 0; i < retries; i++ {
+				// prepare the request for this attempt (e.g replay its body)
+				r, rerr := attemptRequest(req, i)
+				if rerr != nil {
+					return nil, rerr
+				}
+
 				// attempt the request
-				res, err = c.Do(req)
+				res, err = c.Do(r)
 				if err != nil {
 					// retry on failure
 					continue
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// PatternRule describes a mocked response for requests whose path matches Pattern
+// The whole path has to match, e.g `/users/(\d+)` matches `/users/42` but not `/users/42/posts`
+type PatternRule struct {
+	// Method optionally restricts the rule to a single request method
+	Method  string
+	Pattern string
+
+	// StatusCode and Body make up a static response, StatusCode defaults to 200
+	StatusCode int
+	Body       string
+
+	// RespondFn builds a dynamic response instead, it receives the pattern's captured groups
+	RespondFn func(req *http.Request, groups []string) (*http.Response, error)
+}
+
+// MockByPattern returns an HTTPClient which responds using the first rule whose pattern matches the request path
+// Requests not matching any rule get a 404 response
+// All patterns are compiled upfront, so an invalid pattern results in an error
+func MockByPattern(rules []PatternRule) (HTTPClient, error) {
+	res := make([]*regexp.Regexp, len(rules))
+	for i, r := range rules {
+		re, err := regexp.Compile("^(?:" + r.Pattern + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", r.Pattern, err)
+		}
+		res[i] = re
+	}
+
+	return &MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			for i, r := range rules {
+				if r.Method != "" && r.Method != req.Method {
+					continue
+				}
+
+				groups := res[i].FindStringSubmatch(req.URL.Path)
+				if groups == nil {
+					continue
+				}
+
+				if r.RespondFn != nil {
+					// drop the full match, only pass the captured groups
+					return r.RespondFn(req, groups[1:])
+				}
+
+				code := r.StatusCode
+				if code == 0 {
+					code = http.StatusOK
+				}
+				return stringResponse(req, code, r.Body), nil
+			}
+
+			return stringResponse(req, http.StatusNotFound, "not found"), nil
+		},
+	}, nil
+}
+
+func stringResponse(req *http.Request, code int, body string) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", code, http.StatusText(code)),
+		StatusCode:    code,
+		Header:        http.Header{},
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
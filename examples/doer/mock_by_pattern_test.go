@@ -0,0 +1,53 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestMockByPattern(t *testing.T) {
+	c, err := MockByPattern([]PatternRule{
+		{
+			Method:  "GET",
+			Pattern: `/users/(\d+)`,
+			RespondFn: func(req *http.Request, groups []string) (*http.Response, error) {
+				return stringResponse(req, 200, "user "+groups[0]), nil
+			},
+		},
+		{Pattern: `/health`, Body: "ok"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	tcs := []struct {
+		method, path string
+		expectedCode int
+		expectedBody string
+	}{
+		{"GET", "/users/42", 200, "user 42"},
+		{"POST", "/users/42", 404, "not found"},
+		{"GET", "/users/42/posts", 404, "not found"},
+		{"GET", "/health", 200, "ok"},
+	}
+
+	for _, tc := range tcs {
+		req, _ := http.NewRequest(tc.method, "http://example.com"+tc.path, nil)
+		res, err := c.Do(req)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		bs, _ := io.ReadAll(res.Body)
+		if res.StatusCode != tc.expectedCode || string(bs) != tc.expectedBody {
+			t.Fatalf("%s %s: expected %d %q, got %d %q", tc.method, tc.path, tc.expectedCode, tc.expectedBody, res.StatusCode, bs)
+		}
+	}
+}
+
+func TestMockByPatternInvalidPattern(t *testing.T) {
+	if _, err := MockByPattern([]PatternRule{{Pattern: `/users/(\d+`}}); err == nil {
+		t.Fatal("expected an error for an invalid pattern")
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"sort"
+)
+
+// PostMultipart sends a multipart/form-data POST request with the given fields and files using the given HTTPClient
+// Files are keyed by their form field name, which is also used as the file name
+func PostMultipart(c HTTPClient, url string, fields map[string]string, files map[string]io.Reader) (*http.Response, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+
+	// write parts in a stable order so identical inputs produce identical bodies
+	for _, k := range sortedKeys(fields) {
+		if err := w.WriteField(k, fields[k]); err != nil {
+			return nil, err
+		}
+	}
+
+	fileNames := make([]string, 0, len(files))
+	for k := range files {
+		fileNames = append(fileNames, k)
+	}
+	sort.Strings(fileNames)
+
+	for _, k := range fileNames {
+		fw, err := w.CreateFormFile(k, k)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := io.Copy(fw, files[k]); err != nil {
+			return nil, err
+		}
+	}
+
+	// closing the writer adds the terminating boundary
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	body := buf.Bytes()
+
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+
+	// allow wrappers (e.g retries) to replay the body
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+
+	return c.Do(req)
+}
+
+func sortedKeys(m map[string]string) []string {
+	ks := make([]string, 0, len(m))
+	for k := range m {
+		ks = append(ks, k)
+	}
+	sort.Strings(ks)
+	return ks
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// readMultipart parses a multipart body into a map of form field name to (file name, contents)
+func readMultipart(t *testing.T, contentType string, body io.Reader) map[string][2]string {
+	t.Helper()
+
+	mt, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mt != "multipart/form-data" {
+		t.Fatalf("expected multipart/form-data, got %s", mt)
+	}
+
+	parts := map[string][2]string{}
+
+	mr := multipart.NewReader(body, params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		bs, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts[part.FormName()] = [2]string{part.FileName(), string(bs)}
+	}
+
+	return parts
+}
+
+func TestPostMultipart(t *testing.T) {
+	var got, replayed map[string][2]string
+
+	c := &MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			if req.Method != "POST" {
+				t.Errorf("expected POST, got %s", req.Method)
+			}
+
+			got = readMultipart(t, req.Header.Get("Content-Type"), req.Body)
+
+			body, err := req.GetBody()
+			if err != nil {
+				t.Fatal(err)
+			}
+			replayed = readMultipart(t, req.Header.Get("Content-Type"), body)
+
+			return stringResponse(req, 200, "ok"), nil
+		},
+	}
+
+	_, err := PostMultipart(c, "http://example.com/upload",
+		map[string]string{"name": "kip", "age": "3"},
+		map[string]io.Reader{"avatar": strings.NewReader("image-bytes")},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string][2]string{
+		"name":   {"", "kip"},
+		"age":    {"", "3"},
+		"avatar": {"avatar", "image-bytes"},
+	}
+
+	for _, parts := range []map[string][2]string{got, replayed} {
+		if len(parts) != len(expected) {
+			t.Fatalf("expected %d parts, got %v", len(expected), parts)
+		}
+		for k, v := range expected {
+			if parts[k] != v {
+				t.Errorf("part %s: expected %v, got %v", k, v, parts[k])
+			}
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// cachedResponse is a buffered copy of a response which can be replayed many times
+type cachedResponse struct {
+	statusCode int
+	header     http.Header
+	body       []byte
+	expires    time.Time
+}
+
+// newCachedResponse buffers the given response, the response body is replaced so it can still be read
+func newCachedResponse(res *http.Response, ttl time.Duration) (*cachedResponse, error) {
+	var body []byte
+	if res.Body != nil {
+		bs, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = bs
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	return &cachedResponse{
+		statusCode: res.StatusCode,
+		header:     res.Header.Clone(),
+		body:       body,
+		expires:    time.Now().Add(ttl),
+	}, nil
+}
+
+// response builds a new response from the cached copy
+func (r *cachedResponse) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.statusCode, http.StatusText(r.statusCode)),
+		StatusCode:    r.statusCode,
+		Header:        r.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(r.body)),
+		ContentLength: int64(len(r.body)),
+		Request:       req,
+	}
+}
+
+// NegativeCacheHTTPClient caches responses to GET requests by URL
+// Successful (2xx) responses are cached for posTTL, while error (4xx/5xx) responses are cached for negTTL
+// This avoids hammering the server for requests known to be bad, without holding on to failures for too long
+// Transport errors and other responses are not cached
+// Requests made with a NoCache context are always sent
+func NegativeCacheHTTPClient(c HTTPClient, posTTL, negTTL time.Duration) HTTPClient {
+	var mu sync.Mutex
+	cache := map[string]*cachedResponse{}
+
+	return &MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			if req.Method != "GET" {
+				return c.Do(req)
+			}
+
+			key := req.URL.String()
+
+			// requests marked with NoCache skip the lookup, but still refresh the cache
+			if !isNoCache(req.Context()) {
+				mu.Lock()
+				cr, ok := cache[key]
+				if ok && time.Now().Before(cr.expires) {
+					mu.Unlock()
+					return cr.response(req), nil
+				}
+				mu.Unlock()
+			}
+
+			res, err := c.Do(req)
+			if err != nil {
+				return nil, err
+			}
+
+			var ttl time.Duration
+			switch {
+			case res.StatusCode >= 200 && res.StatusCode < 300:
+				ttl = posTTL
+			case res.StatusCode >= 400:
+				ttl = negTTL
+			default:
+				return res, nil
+			}
+
+			cr, err := newCachedResponse(res, ttl)
+			if err != nil {
+				return nil, err
+			}
+
+			mu.Lock()
+			cache[key] = cr
+			mu.Unlock()
+
+			return res, nil
+		},
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNegativeCacheHTTPClient(t *testing.T) {
+	calls := map[string]int{}
+	c := NegativeCacheHTTPClient(&MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			calls[req.URL.Path]++
+			if req.URL.Path == "/missing" {
+				return stringResponse(req, 404, "not found"), nil
+			}
+			return stringResponse(req, 200, "found"), nil
+		},
+	}, time.Hour, 20*time.Millisecond)
+
+	get := func(path string) *http.Response {
+		req, _ := http.NewRequest("GET", "http://example.com"+path, nil)
+		res, err := c.Do(req)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		return res
+	}
+
+	for i := 0; i < 3; i++ {
+		get("/missing")
+		get("/found")
+	}
+	if calls["/missing"] != 1 || calls["/found"] != 1 {
+		t.Fatalf("expected both responses to be cached, got %v", calls)
+	}
+
+	res := get("/missing")
+	if bs, _ := io.ReadAll(res.Body); res.StatusCode != 404 || string(bs) != "not found" {
+		t.Fatalf("expected cached 404 response, got %d %q", res.StatusCode, bs)
+	}
+
+	// the negative entry expires, while the positive one is still fresh
+	time.Sleep(30 * time.Millisecond)
+
+	get("/missing")
+	get("/found")
+	if calls["/missing"] != 2 {
+		t.Fatalf("expected 404 to expire after negTTL, got %d calls", calls["/missing"])
+	}
+	if calls["/found"] != 1 {
+		t.Fatalf("expected 200 to be cached for posTTL, got %d calls", calls["/found"])
+	}
+}
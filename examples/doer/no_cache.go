@@ -0,0 +1,19 @@
+package main
+
+import (
+	"context"
+)
+
+type noCacheKey struct{}
+
+// NoCache returns a context which marks requests made with it as bypassing any cached responses
+// Cache wrappers will always send such requests, and refresh their cache with the new response
+func NoCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noCacheKey{}, true)
+}
+
+// isNoCache reports whether the given context was marked with NoCache
+func isNoCache(ctx context.Context) bool {
+	v, _ := ctx.Value(noCacheKey{}).(bool)
+	return v
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNoCache(t *testing.T) {
+	calls := 0
+	c := NegativeCacheHTTPClient(&MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			calls++
+			return stringResponse(req, 200, "fresh"), nil
+		},
+	}, time.Hour, time.Hour)
+
+	get := func(ctx context.Context) {
+		req, _ := http.NewRequestWithContext(ctx, "GET", "http://example.com", nil)
+		res, err := c.Do(req)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if bs, _ := io.ReadAll(res.Body); string(bs) != "fresh" {
+			t.Fatalf("unexpected body %q", bs)
+		}
+	}
+
+	// populate the cache
+	get(context.Background())
+	get(context.Background())
+	if calls != 1 {
+		t.Fatalf("expected the second request to be served from cache, got %d calls", calls)
+	}
+
+	get(NoCache(context.Background()))
+	if calls != 2 {
+		t.Fatalf("expected NoCache to bypass the cache, got %d calls", calls)
+	}
+}
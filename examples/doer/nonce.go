@@ -0,0 +1,49 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// NonceHTTPClient sets a unique nonce in the given header of each request, for APIs requiring replay protection
+// Nonces are strictly increasing, also across concurrent requests, and start from the current time
+// so they keep increasing across restarts
+func NonceHTTPClient(c HTTPClient, header string) HTTPClient {
+	return nonceHTTPClient(c, header, func(req *http.Request, nonce string) {})
+}
+
+// NonceHMACHTTPClient is the same as NonceHTTPClient,
+// but additionally signs the nonce, method and URL of each request with HMAC-SHA256 using the given key
+// The hex encoded signature is set in sigHeader
+func NonceHMACHTTPClient(c HTTPClient, header, sigHeader string, key []byte) HTTPClient {
+	return nonceHTTPClient(c, header, func(req *http.Request, nonce string) {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(nonce + "\n" + req.Method + "\n" + req.URL.String()))
+		req.Header.Set(sigHeader, hex.EncodeToString(mac.Sum(nil)))
+	})
+}
+
+func nonceHTTPClient(c HTTPClient, header string, sign func(req *http.Request, nonce string)) HTTPClient {
+	last := time.Now().UnixNano()
+
+	return &MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			nonce := strconv.FormatInt(atomic.AddInt64(&last, 1), 10)
+
+			// don't modify the caller's request
+			r := req.Clone(req.Context())
+			if r.Header == nil {
+				r.Header = http.Header{}
+			}
+			r.Header.Set(header, nonce)
+			sign(r, nonce)
+
+			return c.Do(r)
+		},
+	}
+}
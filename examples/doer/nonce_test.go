@@ -0,0 +1,69 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+func TestNonceHTTPClient(t *testing.T) {
+	var mu sync.Mutex
+	seen := map[string]bool{}
+
+	c := NonceHTTPClient(&MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			seen[req.Header.Get("X-Nonce")] = true
+			return stringResponse(req, 200, "ok"), nil
+		},
+	}, "X-Nonce")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 1000; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest("GET", "http://example.com", nil)
+			if _, err := c.Do(req); err != nil {
+				t.Errorf("unexpected error %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(seen) != 1000 {
+		t.Fatalf("expected 1000 unique nonces, got %d", len(seen))
+	}
+}
+
+func TestNonceHMACHTTPClient(t *testing.T) {
+	key := []byte("secret")
+
+	var got *http.Request
+	c := NonceHMACHTTPClient(&MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			got = req
+			return stringResponse(req, 200, "ok"), nil
+		},
+	}, "X-Nonce", "X-Signature", key)
+
+	req, _ := http.NewRequest("POST", "http://example.com/orders", nil)
+	if _, err := c.Do(req); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	nonce := got.Header.Get("X-Nonce")
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(nonce + "\nPOST\nhttp://example.com/orders"))
+	if expected := hex.EncodeToString(mac.Sum(nil)); got.Header.Get("X-Signature") != expected {
+		t.Fatalf("expected signature %s, got %s", expected, got.Header.Get("X-Signature"))
+	}
+
+	if req.Header.Get("X-Nonce") != "" {
+		t.Fatal("expected the caller's request to be left untouched")
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// NormalizeURL returns a canonical copy of the given URL:
+// lowercase scheme and host, no default port, sorted query params and resolved `.`/`..` path segments
+func NormalizeURL(u *url.URL) *url.URL {
+	n := *u
+
+	n.Scheme = strings.ToLower(n.Scheme)
+	n.Host = strings.ToLower(n.Host)
+
+	// drop ports which are implied by the scheme
+	if host, port, err := net.SplitHostPort(n.Host); err == nil {
+		if (n.Scheme == "http" && port == "80") || (n.Scheme == "https" && port == "443") {
+			n.Host = host
+			// SplitHostPort strips the brackets off IPv6 hosts
+			if strings.Contains(host, ":") {
+				n.Host = "[" + host + "]"
+			}
+		}
+	}
+
+	if n.Path != "" {
+		p := path.Clean(n.Path)
+
+		// Clean drops trailing slashes, which can be significant
+		if strings.HasSuffix(n.Path, "/") && p != "/" {
+			p += "/"
+		}
+		n.Path = p
+		n.RawPath = ""
+	}
+
+	// Encode sorts by key
+	if n.RawQuery != "" {
+		n.RawQuery = n.Query().Encode()
+	}
+
+	return &n
+}
+
+// NormalizeURLHTTPClient normalizes request URLs (see NormalizeURL) before sending them
+// Place it in front of caching or de-duplicating clients so equivalent URLs share the same key
+// The caller's request is left untouched
+func NormalizeURLHTTPClient(c HTTPClient) HTTPClient {
+	return &MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			r := req.Clone(req.Context())
+			r.URL = NormalizeURL(req.URL)
+
+			// keep any explicitly overridden Host header
+			if req.Host == req.URL.Host {
+				r.Host = r.URL.Host
+			}
+
+			return c.Do(r)
+		},
+	}
+}
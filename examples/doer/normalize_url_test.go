@@ -0,0 +1,67 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	equivalent := []string{
+		"http://example.com/a/b/?x=1&y=2",
+		"HTTP://EXAMPLE.com:80/a/b/?y=2&x=1",
+		"http://example.com/a/./c/../b/?x=1&y=2",
+	}
+
+	expected := NormalizeURL(mustParseURL(t, equivalent[0])).String()
+	for _, raw := range equivalent[1:] {
+		if got := NormalizeURL(mustParseURL(t, raw)).String(); got != expected {
+			t.Fatalf("expected %s to normalize to %s, got %s", raw, expected, got)
+		}
+	}
+
+	// the trailing slash and non-default ports are significant
+	different := []string{
+		"http://example.com/a/b?x=1&y=2",
+		"http://example.com:8080/a/b/?x=1&y=2",
+		"https://example.com/a/b/?x=1&y=2",
+	}
+	for _, raw := range different {
+		if got := NormalizeURL(mustParseURL(t, raw)).String(); got == expected {
+			t.Fatalf("expected %s not to normalize to %s", raw, expected)
+		}
+	}
+}
+
+func TestNormalizeURLHTTPClient(t *testing.T) {
+	var got string
+	c := NormalizeURLHTTPClient(&MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			got = req.URL.String()
+			return stringResponse(req, 200, "ok"), nil
+		},
+	})
+
+	raw := "HTTP://Example.com:80/a/../b?z=1&a=2"
+	req, _ := http.NewRequest("GET", raw, nil)
+	orig := req.URL.String()
+	if _, err := c.Do(req); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if expected := "http://example.com/b?a=2&z=1"; got != expected {
+		t.Fatalf("expected %s, got %s", expected, got)
+	}
+	if req.URL.String() != orig {
+		t.Fatalf("expected the caller's request to be left untouched, got %s", req.URL)
+	}
+}
+
+func mustParseURL(t *testing.T, raw string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
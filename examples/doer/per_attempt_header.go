@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"net/http"
+)
+
+type attemptKey struct{}
+
+// Attempt returns the (one based) attempt number of a request with the given context
+// It's set by the retry wrappers, requests which weren't retried are always on their first attempt
+func Attempt(ctx context.Context) int {
+	if n, ok := ctx.Value(attemptKey{}).(int); ok {
+		return n
+	}
+	return 1
+}
+
+// PerAttemptHeaderHTTPClient lets mutate update the headers of each attempt of a request,
+// e.g to set an attempt counter header or refresh an auth token
+// Place it inside retry wrappers so it sees every attempt, the caller's request is left untouched
+func PerAttemptHeaderHTTPClient(c HTTPClient, mutate func(attempt int, req *http.Request)) HTTPClient {
+	return &MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			r := req.Clone(req.Context())
+			if r.Header == nil {
+				r.Header = http.Header{}
+			}
+
+			mutate(Attempt(req.Context()), r)
+
+			return c.Do(r)
+		},
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestPerAttemptHeaderHTTPClient(t *testing.T) {
+	tcs := []struct {
+		name     string
+		wrap     func(c HTTPClient) HTTPClient
+		expected []string
+	}{
+		{"RetryHTTPClient", func(c HTTPClient) HTTPClient { return RetryHTTPClient(c, 3) }, []string{"1", "2", "3"}},
+		{"RetryWithDelayFuncHTTPClient", func(c HTTPClient) HTTPClient {
+			return RetryWithDelayFuncHTTPClient(c, 2, func(attempt int) time.Duration { return 0 })
+		}, []string{"1", "2", "3"}},
+		{"RetryIdempotentHTTPClient", func(c HTTPClient) HTTPClient { return RetryIdempotentHTTPClient(c) }, []string{"1", "2"}},
+		{"ResilientHTTPClient", func(c HTTPClient) HTTPClient {
+			return ResilientHTTPClient(c, ResilienceConfig{MaxAttempts: 3, BaseDelay: time.Millisecond})
+		}, []string{"1", "2", "3"}},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			seen := []string{}
+
+			// keeps failing with a connection reset until the last expected attempt
+			backend := &MockHTTPClient{
+				DoFn: func(req *http.Request) (*http.Response, error) {
+					seen = append(seen, req.Header.Get("X-Attempt"))
+					if len(seen) < len(tc.expected) {
+						return nil, io.EOF
+					}
+					return stringResponse(req, 200, "ok"), nil
+				},
+			}
+
+			c := tc.wrap(PerAttemptHeaderHTTPClient(backend, func(attempt int, req *http.Request) {
+				req.Header.Set("X-Attempt", strconv.Itoa(attempt))
+			}))
+
+			req, _ := http.NewRequest("GET", "http://example.com", nil)
+			if _, err := c.Do(req); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			if !reflect.DeepEqual(seen, tc.expected) {
+				t.Fatalf("expected attempt headers %v, got %v", tc.expected, seen)
+			}
+			if req.Header.Get("X-Attempt") != "" {
+				t.Fatal("expected the caller's request to be left untouched")
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// hostBucketIdle is how long a host's bucket may go unused before it is evicted
+const hostBucketIdle = time.Minute
+
+// tokenBucket allows `burst` requests at once, refilling at `rps` tokens per second
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// PerHostRateLimitHTTPClient limits requests to `rps` requests per second (with bursts of up to `burst`)
+// A separate limit is kept per request host, so a busy host doesn't slow down requests to other hosts
+// Requests wait for their turn, or until their context is done
+// A non-positive rps disables the limit
+func PerHostRateLimitHTTPClient(c HTTPClient, rps float64, burst int) HTTPClient {
+	if rps <= 0 {
+		return c
+	}
+
+	var mu sync.Mutex
+	buckets := map[string]*tokenBucket{}
+	lastSweep := time.Now()
+
+	// reserve takes a token from the host's bucket and returns how long to wait before it may be used
+	reserve := func(host string) time.Duration {
+		mu.Lock()
+		defer mu.Unlock()
+
+		now := time.Now()
+
+		// evict idle buckets, they would have refilled completely anyway
+		if now.Sub(lastSweep) > hostBucketIdle {
+			for h, b := range buckets {
+				if now.Sub(b.last) > hostBucketIdle {
+					delete(buckets, h)
+				}
+			}
+			lastSweep = now
+		}
+
+		b, ok := buckets[host]
+		if !ok {
+			b = &tokenBucket{tokens: float64(burst), last: now}
+			buckets[host] = b
+		}
+
+		// refill according to the time passed
+		b.tokens += now.Sub(b.last).Seconds() * rps
+		if b.tokens > float64(burst) {
+			b.tokens = float64(burst)
+		}
+		b.last = now
+
+		// tokens can go negative, which queues up later requests behind this one
+		b.tokens--
+		if b.tokens >= 0 {
+			return 0
+		}
+
+		return time.Duration(-b.tokens / rps * float64(time.Second))
+	}
+
+	return &MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			if wait := reserve(req.URL.Host); wait > 0 {
+				if err := sleepCtx(req.Context(), wait); err != nil {
+					return nil, err
+				}
+			}
+
+			return c.Do(req)
+		},
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestPerHostRateLimitHTTPClient(t *testing.T) {
+	c := PerHostRateLimitHTTPClient(&MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			return stringResponse(req, 200, "ok"), nil
+		},
+	}, 10, 1)
+
+	// do returns how long the request took
+	do := func(url string) time.Duration {
+		start := time.Now()
+		req, _ := http.NewRequest("GET", url, nil)
+		if _, err := c.Do(req); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		return time.Since(start)
+	}
+
+	if d := do("http://a.example.com"); d > 50*time.Millisecond {
+		t.Fatalf("expected the first request to go through immediately, took %s", d)
+	}
+
+	// host a used up its burst, host b didn't
+	if d := do("http://b.example.com"); d > 50*time.Millisecond {
+		t.Fatalf("expected other hosts to be unaffected, took %s", d)
+	}
+	if d := do("http://a.example.com"); d < 50*time.Millisecond {
+		t.Fatalf("expected the second request to wait for a token, took %s", d)
+	}
+}
+
+func TestPerHostRateLimitHTTPClientNoLimit(t *testing.T) {
+	c := PerHostRateLimitHTTPClient(&MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			return stringResponse(req, 200, "ok"), nil
+		},
+	}, 0, 0)
+
+	for i := 0; i < 10; i++ {
+		req, _ := http.NewRequest("GET", "http://a.example.com", nil)
+		if _, err := c.Do(req); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestPostJSON(t *testing.T) {
+	type order struct {
+		ID    int      `json:"id"`
+		Items []string `json:"items"`
+	}
+
+	var got *http.Request
+	var body []byte
+	c := &MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			got = req
+			body, _ = io.ReadAll(req.Body)
+			return stringResponse(req, 201, "created"), nil
+		},
+	}
+
+	res, err := PostJSON(c, "http://example.com/orders", order{ID: 1, Items: []string{"a", "b"}})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if res.StatusCode != 201 {
+		t.Fatalf("expected 201, got %d", res.StatusCode)
+	}
+
+	if got.Method != "POST" || got.Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("expected a JSON POST, got %s with content type %q", got.Method, got.Header.Get("Content-Type"))
+	}
+
+	var o order
+	if err := json.Unmarshal(body, &o); err != nil || o.ID != 1 || len(o.Items) != 2 {
+		t.Fatalf("expected the encoded payload, got %s", body)
+	}
+
+	// the body can be replayed
+	rb, _ := got.GetBody()
+	if replayed, _ := io.ReadAll(rb); string(replayed) != string(body) {
+		t.Fatalf("expected GetBody to replay %s, got %s", body, replayed)
+	}
+}
+
+func TestPostJSONMarshalError(t *testing.T) {
+	calls := 0
+	c := &MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			calls++
+			return stringResponse(req, 200, "ok"), nil
+		},
+	}
+
+	if _, err := PostJSON(c, "http://example.com", make(chan int)); err == nil {
+		t.Fatal("expected a marshal error")
+	}
+	if calls != 0 {
+		t.Fatalf("expected nothing to be sent, got %d calls", calls)
+	}
+}
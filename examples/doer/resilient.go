@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ResilienceConfig configures ResilientHTTPClient
+type ResilienceConfig struct {
+	// MaxAttempts is the total number of attempts made for a request, including the first one
+	MaxAttempts int
+
+	// BaseDelay and MaxDelay bound the (decorrelated jitter) backoff between attempts
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+
+	// AttemptTimeout limits each individual attempt, zero means no limit
+	// The request context limits all attempts together
+	AttemptTimeout time.Duration
+
+	// Retryable decides whether an attempt should be retried
+	// Defaults to retrying errors, 429 and 5xx responses
+	Retryable func(res *http.Response, err error) bool
+
+	// Rand is used to pick backoff delays, defaults to the math/rand global source
+	Rand *rand.Rand
+}
+
+// DefaultRetryable retries on errors, 429 and 5xx responses
+func DefaultRetryable(res *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500
+}
+
+// ResilientHTTPClient wraps an HTTPClient with retries, decorrelated jitter backoff and per-attempt timeouts
+// Backoff stops early once it would exceed the request context deadline
+func ResilientHTTPClient(c HTTPClient, cfg ResilienceConfig) HTTPClient {
+	if cfg.MaxAttempts < 1 {
+		cfg.MaxAttempts = 1
+	}
+	if cfg.Retryable == nil {
+		cfg.Retryable = DefaultRetryable
+	}
+
+	// rand.Rand isn't safe for concurrent use
+	var randMu sync.Mutex
+	int63n := func(n int64) int64 {
+		if cfg.Rand == nil {
+			return rand.Int63n(n)
+		}
+		randMu.Lock()
+		defer randMu.Unlock()
+		return cfg.Rand.Int63n(n)
+	}
+
+	return &MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			ctx := req.Context()
+
+			var res *http.Response
+			var err error
+
+			delay := cfg.BaseDelay
+
+			for attempt := 0; attempt < cfg.MaxAttempts; attempt++ {
+				if attempt > 0 {
+					delay = decorrelatedJitter(cfg.BaseDelay, cfg.MaxDelay, delay, int63n)
+
+					// no point in sleeping if we'll run out of time anyway
+					if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+						break
+					}
+
+					if serr := sleepCtx(ctx, delay); serr != nil {
+						break
+					}
+
+					// discard the previous response before trying again
+					if res != nil && res.Body != nil {
+						res.Body.Close()
+					}
+				}
+
+				areq, rerr := attemptRequest(req, attempt)
+				if rerr != nil {
+					return nil, rerr
+				}
+
+				res, err = doAttempt(c, areq, cfg.AttemptTimeout)
+				if !cfg.Retryable(res, err) {
+					return res, err
+				}
+			}
+
+			return res, err
+		},
+	}
+}
+
+// decorrelatedJitter picks the next backoff as min(maxDelay, random(base, prev*3))
+func decorrelatedJitter(base, maxDelay, prev time.Duration, int63n func(int64) int64) time.Duration {
+	upper := prev * 3
+	if upper <= base {
+		return minDuration(base, maxDelay)
+	}
+
+	d := base + time.Duration(int63n(int64(upper-base)))
+	return minDuration(d, maxDelay)
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if b > 0 && b < a {
+		return b
+	}
+	return a
+}
+
+// sleepCtx sleeps for d, or until ctx is done
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// attemptRequest prepares req for the given (zero based) attempt, replaying its body if needed
+// The attempt number is recorded in the request context, see Attempt
+func attemptRequest(req *http.Request, attempt int) (*http.Request, error) {
+	return replayRequest(context.WithValue(req.Context(), attemptKey{}, attempt+1), req, attempt > 0)
+}
+
+// replayRequest clones req with the given context, and if replay is set gives the clone a fresh copy of the body
+func replayRequest(ctx context.Context, req *http.Request, replay bool) (*http.Request, error) {
+	r := req.Clone(ctx)
+
+	if !replay || req.Body == nil || req.GetBody == nil {
+		return r, nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+
+	r.Body = body
+	return r, nil
+}
+
+// doAttempt sends req with an optional timeout
+// The timeout keeps applying while the response body is being read
+func doAttempt(c HTTPClient, req *http.Request, timeout time.Duration) (*http.Response, error) {
+	if timeout <= 0 {
+		return c.Do(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+
+	res, err := c.Do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	if res.Body == nil {
+		cancel()
+		return res, nil
+	}
+
+	res.Body = &cancelOnClose{ReadCloser: res.Body, cancel: cancel}
+	return res, nil
+}
+
+// cancelOnClose calls cancel once the body it's attached to is closed, e.g to release a context
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestDecorrelatedJitter(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	base, maxDelay := 10*time.Millisecond, 200*time.Millisecond
+
+	prev := base
+	for i := 0; i < 1000; i++ {
+		d := decorrelatedJitter(base, maxDelay, prev, r.Int63n)
+
+		upper := minDuration(prev*3, maxDelay)
+		if d < base || d > upper {
+			t.Fatalf("expected delay within [%s, %s], got %s", base, upper, d)
+		}
+		prev = d
+	}
+}
+
+// blockingClient blocks each request until its context is done
+func blockingClient(calls *int) HTTPClient {
+	return &MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			*calls++
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		},
+	}
+}
+
+func TestResilientHTTPClientAttemptTimeout(t *testing.T) {
+	calls := 0
+	c := ResilientHTTPClient(blockingClient(&calls), ResilienceConfig{
+		MaxAttempts:    3,
+		BaseDelay:      time.Millisecond,
+		AttemptTimeout: 10 * time.Millisecond,
+	})
+
+	req, _ := http.NewRequest("GET", "http://example.com", nil)
+	if _, err := c.Do(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	// each attempt times out on its own, so all of them are made
+	if calls != 3 {
+		t.Fatalf("expected 3 attempts, got %d", calls)
+	}
+}
+
+func TestResilientHTTPClientOverallDeadline(t *testing.T) {
+	calls := 0
+	c := ResilientHTTPClient(blockingClient(&calls), ResilienceConfig{
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", "http://example.com", nil)
+	if _, err := c.Do(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	// the request deadline covers all attempts, so there's no time left to retry
+	if calls != 1 {
+		t.Fatalf("expected 1 attempt, got %d", calls)
+	}
+}
+
+func TestResilientHTTPClientRetryable(t *testing.T) {
+	tcs := []struct {
+		name          string
+		retryable     func(res *http.Response, err error) bool
+		expectedCalls int
+	}{
+		{"default retries 503", nil, 3},
+		{"predicate refuses 503", func(res *http.Response, err error) bool {
+			return err != nil
+		}, 1},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			c := ResilientHTTPClient(&MockHTTPClient{
+				DoFn: func(req *http.Request) (*http.Response, error) {
+					calls++
+					return stringResponse(req, 503, "unavailable"), nil
+				},
+			}, ResilienceConfig{
+				MaxAttempts: 3,
+				BaseDelay:   time.Millisecond,
+				Retryable:   tc.retryable,
+			})
+
+			req, _ := http.NewRequest("GET", "http://example.com", nil)
+			res, err := c.Do(req)
+			if err != nil || res.StatusCode != 503 {
+				t.Fatalf("expected the last 503 response, got %v, %v", res, err)
+			}
+			if calls != tc.expectedCalls {
+				t.Fatalf("expected %d calls, got %d", tc.expectedCalls, calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"time"
+)
+
+// RetryWithDelayFuncHTTPClient wraps an HTTPClient with retry functionality
+// Before each retry it waits for the duration returned by delayFn, which receives the retry number (starting at 1)
+// This allows plugging in any backoff strategy, e.g constant, linear or exponential
+// Unlike RetryHTTPClient's retries, maxRetries doesn't count the first attempt,
+// so the request is sent at most maxRetries+1 times
+// Waiting is cut short if the request context is done
+func RetryWithDelayFuncHTTPClient(c HTTPClient, maxRetries int, delayFn func(attempt int) time.Duration) HTTPClient {
+	return &MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			var res *http.Response
+			var err error
+
+			// one initial attempt, followed by up to `maxRetries` retries
+			for attempt := 0; attempt <= maxRetries; attempt++ {
+				if attempt > 0 {
+					if serr := sleepCtx(req.Context(), delayFn(attempt)); serr != nil {
+						return nil, serr
+					}
+				}
+
+				r, rerr := attemptRequest(req, attempt)
+				if rerr != nil {
+					return nil, rerr
+				}
+
+				res, err = c.Do(r)
+				if err == nil {
+					return res, nil
+				}
+			}
+
+			// we ran out of retries and never succeeded
+			return nil, err
+		},
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRetryWithDelayFuncHTTPClient(t *testing.T) {
+	base := time.Millisecond
+
+	tcs := []struct {
+		name     string
+		delayFn  func(attempt int) time.Duration
+		expected []time.Duration
+	}{
+		{"constant", func(attempt int) time.Duration { return base }, []time.Duration{base, base, base}},
+		{"doubling", func(attempt int) time.Duration { return base << uint(attempt-1) }, []time.Duration{base, 2 * base, 4 * base}},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			delays := []time.Duration{}
+
+			c := RetryWithDelayFuncHTTPClient(&MockHTTPClient{
+				DoFn: func(req *http.Request) (*http.Response, error) {
+					calls++
+					return nil, errors.New("unavailable")
+				},
+			}, 3, func(attempt int) time.Duration {
+				d := tc.delayFn(attempt)
+				delays = append(delays, d)
+				return d
+			})
+
+			start := time.Now()
+			req, _ := http.NewRequest("GET", "http://example.com", nil)
+			if _, err := c.Do(req); err == nil {
+				t.Fatal("expected an error")
+			}
+
+			if calls != 4 {
+				t.Fatalf("expected 4 calls, got %d", calls)
+			}
+			if !reflect.DeepEqual(delays, tc.expected) {
+				t.Fatalf("expected delays %v, got %v", tc.expected, delays)
+			}
+
+			var total time.Duration
+			for _, d := range tc.expected {
+				total += d
+			}
+			if elapsed := time.Since(start); elapsed < total {
+				t.Fatalf("expected to sleep at least %s, took %s", total, elapsed)
+			}
+		})
+	}
+}
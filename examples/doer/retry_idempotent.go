@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"syscall"
+)
+
+// isIdempotent reports whether requests with the given method can safely be sent more than once
+func isIdempotent(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "TRACE", "PUT", "DELETE":
+		return true
+	}
+	return false
+}
+
+// IsConnReset matches errors caused by a reused keep-alive connection being closed by the server
+func IsConnReset(err error) bool {
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET)
+}
+
+// RetryIdempotentHTTPClient retries idempotent requests exactly once if they fail due to the connection being reset
+// Request bodies are replayed using GetBody, requests with a body that can't be replayed aren't retried
+func RetryIdempotentHTTPClient(c HTTPClient) HTTPClient {
+	return &MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			res, err := c.Do(req)
+			if err == nil || !isIdempotent(req.Method) || !IsConnReset(err) {
+				return res, err
+			}
+
+			if req.Body != nil && req.GetBody == nil {
+				return nil, err
+			}
+
+			r, rerr := attemptRequest(req, 1)
+			if rerr != nil {
+				return nil, err
+			}
+
+			return c.Do(r)
+		},
+	}
+}
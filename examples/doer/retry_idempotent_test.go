@@ -0,0 +1,76 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRetryIdempotentHTTPClient(t *testing.T) {
+	tcs := []struct {
+		name          string
+		method        string
+		expectedCalls int
+		expectErr     bool
+	}{
+		{"GET is retried once", "GET", 2, false},
+		{"POST is not retried", "POST", 1, true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			bodies := []string{}
+			c := RetryIdempotentHTTPClient(&MockHTTPClient{
+				DoFn: func(req *http.Request) (*http.Response, error) {
+					calls++
+					if req.Body != nil {
+						bs, _ := io.ReadAll(req.Body)
+						bodies = append(bodies, string(bs))
+					}
+					// the connection keeps getting reset
+					return nil, io.EOF
+				},
+			})
+
+			req, _ := http.NewRequest(tc.method, "http://example.com", strings.NewReader("payload"))
+			_, err := c.Do(req)
+			if err != io.EOF {
+				t.Fatalf("expected io.EOF, got %v", err)
+			}
+			if calls != tc.expectedCalls {
+				t.Fatalf("expected %d calls, got %d", tc.expectedCalls, calls)
+			}
+
+			// every attempt carries the full body
+			for _, b := range bodies {
+				if b != "payload" {
+					t.Fatalf("expected the body to be replayed, got %q", bodies)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryIdempotentHTTPClientRecovers(t *testing.T) {
+	calls := 0
+	c := RetryIdempotentHTTPClient(&MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			calls++
+			if calls == 1 {
+				return nil, io.ErrUnexpectedEOF
+			}
+			return stringResponse(req, 200, "ok"), nil
+		},
+	})
+
+	req, _ := http.NewRequest("GET", "http://example.com", nil)
+	res, err := c.Do(req)
+	if err != nil || res.StatusCode != 200 {
+		t.Fatalf("expected the retry to succeed, got %v, %v", res, err)
+	}
+	if calls != 2 {
+		t.Fatalf("expected 2 calls, got %d", calls)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"syscall"
+)
+
+// ErrorMatcher reports whether an error is of a certain kind
+type ErrorMatcher func(err error) bool
+
+// IsTimeout matches network timeout errors
+func IsTimeout(err error) bool {
+	var nerr net.Error
+	return errors.As(err, &nerr) && nerr.Timeout()
+}
+
+// IsConnRefused matches errors caused by the remote host refusing the connection
+func IsConnRefused(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// RetryOnErrorsHTTPClient wraps an HTTPClient with retry functionality
+// Unlike RetryHTTPClient, a failed attempt is only retried if one of the given matchers matches its error
+func RetryOnErrorsHTTPClient(c HTTPClient, retries int, matchers ...ErrorMatcher) HTTPClient {
+	return &MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			var res *http.Response
+			var err error
+
+			// try `retries` times
+			for i := 0; i < retries; i++ {
+				r, rerr := attemptRequest(req, i)
+				if rerr != nil {
+					return nil, rerr
+				}
+
+				res, err = c.Do(r)
+				if err == nil {
+					return res, nil
+				}
+
+				// don't bother retrying errors which will never go away (e.g TLS verification failures)
+				if !matchesAny(err, matchers) {
+					return nil, err
+				}
+			}
+
+			// we made `retries` attempts and never succeeded
+			return nil, err
+		},
+	}
+}
+
+func matchesAny(err error, matchers []ErrorMatcher) bool {
+	for _, m := range matchers {
+		if m(err) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"syscall"
+	"testing"
+)
+
+// timeoutError is a net.Error which timed out
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// failingClient fails with each of errs in turn, then succeeds
+func failingClient(calls *int, errs ...error) HTTPClient {
+	return &MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			*calls++
+			if *calls <= len(errs) {
+				return nil, errs[*calls-1]
+			}
+			return stringResponse(req, 200, "ok"), nil
+		},
+	}
+}
+
+func TestRetryOnErrorsHTTPClient(t *testing.T) {
+	tlsErr := &tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}
+
+	tcs := []struct {
+		name          string
+		err           error
+		expectedCalls int
+		expectErr     bool
+	}{
+		{"timeout is retried", fmt.Errorf("dial: %w", timeoutError{}), 2, false},
+		{"conn refused is retried", fmt.Errorf("dial: %w", syscall.ECONNREFUSED), 2, false},
+		{"tls error is not retried", tlsErr, 1, true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			c := RetryOnErrorsHTTPClient(failingClient(&calls, tc.err), 3, IsTimeout, IsConnRefused)
+
+			req, _ := http.NewRequest("GET", "http://example.com", nil)
+			_, err := c.Do(req)
+
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if tc.expectErr && !errors.Is(err, tc.err) {
+				t.Fatalf("expected the original error, got %v", err)
+			}
+			if calls != tc.expectedCalls {
+				t.Fatalf("expected %d calls, got %d", tc.expectedCalls, calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// RetryStats tracks how much extra load retries are adding
+type RetryStats struct {
+	requests uint64
+	retries  uint64
+}
+
+// TotalRequests returns the number of logical requests made
+func (s *RetryStats) TotalRequests() uint64 {
+	return atomic.LoadUint64(&s.requests)
+}
+
+// TotalAttempts returns the number of attempts made, including retries
+func (s *RetryStats) TotalAttempts() uint64 {
+	return s.TotalRequests() + s.TotalRetries()
+}
+
+// TotalRetries returns the number of attempts beyond the first one for each request
+func (s *RetryStats) TotalRetries() uint64 {
+	return atomic.LoadUint64(&s.retries)
+}
+
+// RetryRatio returns the average number of retries per request
+// A growing ratio means retries are amplifying the load on the server
+func (s *RetryStats) RetryRatio() float64 {
+	requests := s.TotalRequests()
+	if requests == 0 {
+		return 0
+	}
+	return float64(s.TotalRetries()) / float64(requests)
+}
+
+// RetryStatsHTTPClient counts every attempt going through the given HTTPClient
+// Place it inside any retry wrappers, first attempts count as new requests and later ones (see Attempt) as retries, e.g:
+//
+//	inner, stats := RetryStatsHTTPClient(c)
+//	rc := RetryHTTPClient(inner, 3)
+func RetryStatsHTTPClient(c HTTPClient) (HTTPClient, *RetryStats) {
+	s := &RetryStats{}
+
+	return &MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			if Attempt(req.Context()) > 1 {
+				atomic.AddUint64(&s.retries, 1)
+			} else {
+				atomic.AddUint64(&s.requests, 1)
+			}
+			return c.Do(req)
+		},
+	}, s
+}
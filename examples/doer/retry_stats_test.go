@@ -0,0 +1,42 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestRetryStatsHTTPClient(t *testing.T) {
+	// every request fails twice before succeeding
+	failures := map[string]int{}
+	inner, stats := RetryStatsHTTPClient(&MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			if failures[req.URL.Path] < 2 {
+				failures[req.URL.Path]++
+				return nil, errors.New("unavailable")
+			}
+			return stringResponse(req, 200, "ok"), nil
+		},
+	})
+	c := RetryHTTPClient(inner, 5)
+
+	for _, path := range []string{"/a", "/b", "/c"} {
+		req, _ := http.NewRequest("GET", "http://example.com"+path, nil)
+		if _, err := c.Do(req); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+
+	if stats.TotalRequests() != 3 {
+		t.Fatalf("expected 3 requests, got %d", stats.TotalRequests())
+	}
+	if stats.TotalRetries() != 6 {
+		t.Fatalf("expected 6 retries, got %d", stats.TotalRetries())
+	}
+	if stats.TotalAttempts() != 9 {
+		t.Fatalf("expected 9 attempts, got %d", stats.TotalAttempts())
+	}
+	if stats.RetryRatio() != 2 {
+		t.Fatalf("expected a retry ratio of 2, got %f", stats.RetryRatio())
+	}
+}
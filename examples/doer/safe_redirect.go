@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrTooManyRedirects is returned when a redirect chain is longer than allowed
+	ErrTooManyRedirects = errors.New("too many redirects")
+
+	// ErrCrossHostRedirect is returned when a redirect to a different host is refused
+	ErrCrossHostRedirect = errors.New("redirect to a different host is not allowed")
+)
+
+// SafeRedirectHTTPClient follows redirects returned by the given HTTPClient (which shouldn't follow redirects itself)
+// At most maxHops redirects are followed, and when sameHostOnly is set redirects to other hosts are refused
+// When redirected to another host, the Authorization and Cookie headers are dropped so credentials don't leak
+func SafeRedirectHTTPClient(c HTTPClient, maxHops int, sameHostOnly bool) HTTPClient {
+	return &MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			origHost := req.URL.Host
+
+			for hops := 0; ; hops++ {
+				res, err := c.Do(req)
+				if err != nil {
+					return nil, err
+				}
+
+				loc := res.Header.Get("Location")
+				if !isRedirect(res.StatusCode) || loc == "" {
+					return res, nil
+				}
+
+				// we won't be needing the redirect response
+				if res.Body != nil {
+					res.Body.Close()
+				}
+
+				if hops >= maxHops {
+					return nil, fmt.Errorf("%w: stopped after %d", ErrTooManyRedirects, maxHops)
+				}
+
+				u, err := req.URL.Parse(loc)
+				if err != nil {
+					return nil, fmt.Errorf("invalid redirect location %q: %w", loc, err)
+				}
+
+				crossHost := !strings.EqualFold(u.Host, origHost)
+				if crossHost && sameHostOnly {
+					return nil, fmt.Errorf("%w: %s to %s", ErrCrossHostRedirect, origHost, u.Host)
+				}
+
+				next, err := redirectRequest(req, res.StatusCode)
+				if err != nil {
+					return nil, err
+				}
+				next.URL = u
+				next.Host = ""
+
+				if crossHost {
+					next.Header.Del("Authorization")
+					next.Header.Del("Cookie")
+				}
+
+				req = next
+			}
+		},
+	}
+}
+
+func isRedirect(code int) bool {
+	switch code {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+// redirectRequest builds the follow-up request for a redirect with the given status code
+// 307 and 308 keep the method and body, others switch to a body-less GET (except for HEAD)
+func redirectRequest(req *http.Request, code int) (*http.Request, error) {
+	next := req.Clone(req.Context())
+	if next.Header == nil {
+		next.Header = http.Header{}
+	}
+
+	if code == http.StatusTemporaryRedirect || code == http.StatusPermanentRedirect {
+		if req.Body != nil {
+			if req.GetBody == nil {
+				return nil, fmt.Errorf("can't follow %d redirect, request body can't be replayed", code)
+			}
+
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			next.Body = body
+		}
+		return next, nil
+	}
+
+	if next.Method != "HEAD" {
+		next.Method = "GET"
+	}
+	next.Body = nil
+	next.GetBody = nil
+	next.ContentLength = 0
+	next.Header.Del("Content-Type")
+	next.Header.Del("Content-Length")
+
+	return next, nil
+}
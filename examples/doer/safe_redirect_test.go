@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// redirectingClient redirects /start to the given location, and answers everything else with a 200
+func redirectingClient(location string, seen *[]*http.Request) HTTPClient {
+	return &MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			*seen = append(*seen, req)
+
+			if req.URL.Path == "/start" {
+				res := stringResponse(req, http.StatusFound, "")
+				res.Header.Set("Location", location)
+				return res, nil
+			}
+			return stringResponse(req, 200, "ok"), nil
+		},
+	}
+}
+
+func TestSafeRedirectHTTPClient(t *testing.T) {
+	t.Run("same host is followed", func(t *testing.T) {
+		seen := []*http.Request{}
+		c := SafeRedirectHTTPClient(redirectingClient("/end", &seen), 3, true)
+
+		req, _ := http.NewRequest("GET", "http://example.com/start", nil)
+		req.Header.Set("Authorization", "Bearer token")
+		res, err := c.Do(req)
+		if err != nil || res.StatusCode != 200 {
+			t.Fatalf("expected the redirect to be followed, got %v, %v", res, err)
+		}
+
+		last := seen[len(seen)-1]
+		if last.URL.String() != "http://example.com/end" || last.Header.Get("Authorization") == "" {
+			t.Fatalf("expected credentials to be kept on the same host, got %s %v", last.URL, last.Header)
+		}
+	})
+
+	t.Run("cross host is blocked", func(t *testing.T) {
+		seen := []*http.Request{}
+		c := SafeRedirectHTTPClient(redirectingClient("http://evil.example.org/end", &seen), 3, true)
+
+		req, _ := http.NewRequest("GET", "http://example.com/start", nil)
+		if _, err := c.Do(req); !errors.Is(err, ErrCrossHostRedirect) {
+			t.Fatalf("expected ErrCrossHostRedirect, got %v", err)
+		}
+		if len(seen) != 1 {
+			t.Fatalf("expected the other host not to be contacted, got %d requests", len(seen))
+		}
+	})
+
+	t.Run("cross host strips credentials", func(t *testing.T) {
+		seen := []*http.Request{}
+		c := SafeRedirectHTTPClient(redirectingClient("http://other.example.org/end", &seen), 3, false)
+
+		req, _ := http.NewRequest("GET", "http://example.com/start", nil)
+		req.Header.Set("Authorization", "Bearer token")
+		req.Header.Set("Cookie", "session=abc")
+		if _, err := c.Do(req); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		last := seen[len(seen)-1]
+		if last.URL.Host != "other.example.org" {
+			t.Fatalf("expected the redirect to be followed, got %s", last.URL)
+		}
+		if last.Header.Get("Authorization") != "" || last.Header.Get("Cookie") != "" {
+			t.Fatalf("expected credentials to be stripped, got %v", last.Header)
+		}
+	})
+
+	t.Run("too many redirects", func(t *testing.T) {
+		seen := []*http.Request{}
+		c := SafeRedirectHTTPClient(redirectingClient("/start", &seen), 2, true)
+
+		req, _ := http.NewRequest("GET", "http://example.com/start", nil)
+		if _, err := c.Do(req); !errors.Is(err, ErrTooManyRedirects) {
+			t.Fatalf("expected ErrTooManyRedirects, got %v", err)
+		}
+		if len(seen) != 3 {
+			t.Fatalf("expected 3 requests, got %d", len(seen))
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// ScatterResult is the outcome of sending a request to one of the ScatterGatherHTTPClient clients
+type ScatterResult struct {
+	Res *http.Response
+	Err error
+}
+
+// ScatterGatherHTTPClient sends each request to all given clients concurrently and combines their responses using merge
+// Results are passed to merge in the same order as the clients, each holding either a response or the client's error
+// merge decides how many failures to tolerate, and is responsible for closing the response bodies
+func ScatterGatherHTTPClient(clients []HTTPClient, merge func([]ScatterResult) (*http.Response, error)) HTTPClient {
+	return &MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			// buffer the body so every backend gets its own copy
+			var body []byte
+			if req.Body != nil {
+				bs, err := io.ReadAll(req.Body)
+				req.Body.Close()
+				if err != nil {
+					return nil, err
+				}
+				body = bs
+			}
+
+			results := make([]ScatterResult, len(clients))
+
+			var wg sync.WaitGroup
+			for i, c := range clients {
+				r := req.Clone(req.Context())
+				if body != nil {
+					r.Body = io.NopCloser(bytes.NewReader(body))
+				}
+
+				wg.Add(1)
+				go func(i int, c HTTPClient, r *http.Request) {
+					defer wg.Done()
+
+					res, err := c.Do(r)
+					results[i] = ScatterResult{Res: res, Err: err}
+				}(i, c, r)
+			}
+			wg.Wait()
+
+			return merge(results)
+		},
+	}
+}
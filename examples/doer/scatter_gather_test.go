@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestScatterGatherHTTPClient(t *testing.T) {
+	errShard := errors.New("shard unavailable")
+
+	clients := make([]HTTPClient, 3)
+	for i := range clients {
+		i := i
+		clients[i] = &MockHTTPClient{
+			DoFn: func(req *http.Request) (*http.Response, error) {
+				if i == 1 {
+					return nil, errShard
+				}
+				bs, _ := io.ReadAll(req.Body)
+				return stringResponse(req, 200, fmt.Sprintf("shard-%d:%s", i, bs)), nil
+			},
+		}
+	}
+
+	var failed []int
+	merge := func(results []ScatterResult) (*http.Response, error) {
+		parts := []string{}
+		for i, r := range results {
+			if r.Err != nil {
+				if !errors.Is(r.Err, errShard) {
+					return nil, fmt.Errorf("unexpected error from shard %d: %w", i, r.Err)
+				}
+				failed = append(failed, i)
+				continue
+			}
+
+			bs, _ := io.ReadAll(r.Res.Body)
+			r.Res.Body.Close()
+			parts = append(parts, string(bs))
+		}
+		return stringResponse(nil, 200, strings.Join(parts, ",")), nil
+	}
+
+	c := ScatterGatherHTTPClient(clients, merge)
+
+	req, _ := http.NewRequest("POST", "http://example.com/search", strings.NewReader("q"))
+	res, err := c.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// every shard gets its own copy of the body, and results keep the client order
+	if bs, _ := io.ReadAll(res.Body); string(bs) != "shard-0:q,shard-2:q" {
+		t.Fatalf("expected the merged responses, got %q", bs)
+	}
+	if len(failed) != 1 || failed[0] != 1 {
+		t.Fatalf("expected shard 1 to report its error, got %v", failed)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+)
+
+// SmartCompressHTTPClient gzips request bodies larger than minBytes, smaller ones aren't worth the CPU cost
+// It also advertises gzip support to the server and transparently decompresses gzip responses
+func SmartCompressHTTPClient(c HTTPClient, minBytes int) HTTPClient {
+	return &MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			// don't modify the caller's request
+			r := req.Clone(req.Context())
+			if r.Header == nil {
+				r.Header = http.Header{}
+			}
+			r.Header.Set("Accept-Encoding", "gzip")
+
+			if req.Body != nil && r.Header.Get("Content-Encoding") == "" {
+				body, err := io.ReadAll(req.Body)
+				req.Body.Close()
+				if err != nil {
+					return nil, err
+				}
+
+				if len(body) > minBytes {
+					var buf bytes.Buffer
+					zw := gzip.NewWriter(&buf)
+					if _, err := zw.Write(body); err != nil {
+						return nil, err
+					}
+					if err := zw.Close(); err != nil {
+						return nil, err
+					}
+
+					body = buf.Bytes()
+					r.Header.Set("Content-Encoding", "gzip")
+				}
+
+				r.Body = io.NopCloser(bytes.NewReader(body))
+				r.ContentLength = int64(len(body))
+				r.GetBody = func() (io.ReadCloser, error) {
+					return io.NopCloser(bytes.NewReader(body)), nil
+				}
+			}
+
+			res, err := c.Do(r)
+			if err != nil {
+				return nil, err
+			}
+
+			if res.Body == nil || res.Header.Get("Content-Encoding") != "gzip" {
+				return res, nil
+			}
+
+			zr, err := gzip.NewReader(res.Body)
+			if err != nil {
+				res.Body.Close()
+				return nil, err
+			}
+
+			// the body is no longer encoded, and its length is unknown
+			res.Body = &gzipBody{Reader: zr, body: res.Body}
+			res.Header.Del("Content-Encoding")
+			res.Header.Del("Content-Length")
+			res.ContentLength = -1
+			res.Uncompressed = true
+
+			return res, nil
+		},
+	}
+}
+
+// gzipBody decompresses a response body, closing it closes the underlying body as well
+type gzipBody struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (b *gzipBody) Close() error {
+	b.Reader.Close()
+	return b.body.Close()
+}
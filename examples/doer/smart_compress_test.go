@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSmartCompressHTTPClient(t *testing.T) {
+	tcs := []struct {
+		name           string
+		body           string
+		expectEncoding string
+	}{
+		{"below threshold", "small", ""},
+		{"above threshold", strings.Repeat("large ", 100), "gzip"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			var encoding, received string
+			c := SmartCompressHTTPClient(&MockHTTPClient{
+				DoFn: func(req *http.Request) (*http.Response, error) {
+					encoding = req.Header.Get("Content-Encoding")
+
+					var r io.Reader = req.Body
+					if encoding == "gzip" {
+						zr, err := gzip.NewReader(req.Body)
+						if err != nil {
+							return nil, err
+						}
+						r = zr
+					}
+					bs, err := io.ReadAll(r)
+					if err != nil {
+						return nil, err
+					}
+					received = string(bs)
+
+					return stringResponse(req, 200, "ok"), nil
+				},
+			}, 100)
+
+			req, _ := http.NewRequest("POST", "http://example.com", strings.NewReader(tc.body))
+			if _, err := c.Do(req); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			if encoding != tc.expectEncoding {
+				t.Fatalf("expected Content-Encoding %q, got %q", tc.expectEncoding, encoding)
+			}
+			if received != tc.body {
+				t.Fatalf("expected the body to arrive intact, got %q", received)
+			}
+		})
+	}
+}
+
+func TestSmartCompressHTTPClientResponse(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte("compressed response"))
+	zw.Close()
+
+	var acceptEncoding string
+	c := SmartCompressHTTPClient(&MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			acceptEncoding = req.Header.Get("Accept-Encoding")
+
+			res := stringResponse(req, 200, buf.String())
+			res.Header.Set("Content-Encoding", "gzip")
+			return res, nil
+		},
+	}, 100)
+
+	req, _ := http.NewRequest("GET", "http://example.com", nil)
+	res, err := c.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	defer res.Body.Close()
+
+	if acceptEncoding != "gzip" {
+		t.Fatalf("expected gzip to be advertised, got %q", acceptEncoding)
+	}
+	if bs, _ := io.ReadAll(res.Body); string(bs) != "compressed response" {
+		t.Fatalf("expected the response to be decompressed, got %q", bs)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrNoClients is returned when a balancing HTTPClient is given no clients to balance between
+var ErrNoClients = errors.New("no clients given")
+
+// StickySessionHTTPClient balances requests between the given clients in a round-robin fashion,
+// except for requests carrying a session cookie (named cookieName) which was issued through one of the clients,
+// those are always sent to that same client
+// At least one client is required, otherwise ErrNoClients is returned
+func StickySessionHTTPClient(clients []HTTPClient, cookieName string) (HTTPClient, error) {
+	if len(clients) == 0 {
+		return nil, ErrNoClients
+	}
+
+	var next uint64
+
+	// maps session cookie values to the index of the client which issued them
+	var sessions sync.Map
+
+	return &MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			i := -1
+
+			if ck, err := req.Cookie(cookieName); err == nil {
+				if v, ok := sessions.Load(ck.Value); ok {
+					i = v.(int)
+				}
+			}
+
+			// no known session, pick the next client in line
+			if i == -1 {
+				i = int((atomic.AddUint64(&next, 1) - 1) % uint64(len(clients)))
+			}
+
+			res, err := clients[i].Do(req)
+			if err != nil {
+				return nil, err
+			}
+
+			// remember which client issued the session
+			for _, ck := range res.Cookies() {
+				if ck.Name == cookieName {
+					sessions.Store(ck.Value, i)
+				}
+			}
+
+			return res, nil
+		},
+	}, nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestStickySessionHTTPClient(t *testing.T) {
+	calls := make([]int, 3)
+	clients := make([]HTTPClient, 3)
+	for i := range clients {
+		i := i
+		clients[i] = &MockHTTPClient{
+			DoFn: func(req *http.Request) (*http.Response, error) {
+				calls[i]++
+				res := stringResponse(req, 200, "ok")
+				if _, err := req.Cookie("session"); err != nil {
+					res.Header.Add("Set-Cookie", fmt.Sprintf("session=backend-%d", i))
+				}
+				return res, nil
+			},
+		}
+	}
+
+	c, err := StickySessionHTTPClient(clients, "session")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// the first request gets a session from whichever backend it lands on
+	req, _ := http.NewRequest("GET", "http://example.com", nil)
+	res, err := c.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	session := res.Cookies()[0]
+
+	for i := 0; i < 5; i++ {
+		req, _ := http.NewRequest("GET", "http://example.com", nil)
+		req.AddCookie(session)
+		if _, err := c.Do(req); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+
+	if session.Value != "backend-0" || calls[0] != 6 || calls[1] != 0 || calls[2] != 0 {
+		t.Fatalf("expected all session requests to go to backend-0, got %s with calls %v", session.Value, calls)
+	}
+}
+
+func TestStickySessionHTTPClientNoClients(t *testing.T) {
+	if _, err := StickySessionHTTPClient(nil, "session"); err != ErrNoClients {
+		t.Fatalf("expected ErrNoClients, got %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"io"
+	"net/http"
+)
+
+// StreamUpload sends a POST request whose body is streamed from the given channel of chunks
+// The body ends once the channel is closed, so data of any size can be uploaded without buffering it
+// If the upload fails, or the response body is closed before all chunks were sent,
+// remaining chunks are discarded so the producer never blocks
+func StreamUpload(c HTTPClient, url string, chunks <-chan []byte) (*http.Response, error) {
+	pr, pw := io.Pipe()
+
+	req, err := http.NewRequest("POST", url, pr)
+	if err != nil {
+		return nil, err
+	}
+
+	// unknown length, the body will be sent chunked
+	req.ContentLength = -1
+
+	go func() {
+		for chunk := range chunks {
+			if _, err := pw.Write(chunk); err != nil {
+				// the reading side is gone, drain the channel so the producer isn't stuck
+				for range chunks {
+				}
+				return
+			}
+		}
+		pw.Close()
+	}()
+
+	res, err := c.Do(req)
+	if err != nil {
+		// unblock the writer
+		pr.CloseWithError(err)
+		return nil, err
+	}
+
+	// the client may return before reading the whole body,
+	// the writer is unblocked once the caller is done with the response
+	if res.Body == nil {
+		pr.Close()
+		return res, nil
+	}
+	res.Body = &cancelOnClose{ReadCloser: res.Body, cancel: func() { pr.Close() }}
+
+	return res, nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestStreamUpload(t *testing.T) {
+	var received string
+	c := &MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			bs, err := io.ReadAll(req.Body)
+			if err != nil {
+				return nil, err
+			}
+			received = string(bs)
+			return stringResponse(req, 200, "ok"), nil
+		},
+	}
+
+	chunks := make(chan []byte)
+	go func() {
+		defer close(chunks)
+		for _, chunk := range []string{"hello", ", ", "world"} {
+			chunks <- []byte(chunk)
+		}
+	}()
+
+	res, err := StreamUpload(c, "http://example.com/upload", chunks)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	res.Body.Close()
+
+	if received != "hello, world" {
+		t.Fatalf("expected chunks to be reassembled, got %q", received)
+	}
+}
+
+func TestStreamUploadUnreadBody(t *testing.T) {
+	// the client responds without ever reading the request body
+	c := &MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			return stringResponse(req, 413, "too large"), nil
+		},
+	}
+
+	chunks := make(chan []byte)
+	produced := make(chan struct{})
+	go func() {
+		defer close(produced)
+		defer close(chunks)
+		for i := 0; i < 10; i++ {
+			chunks <- []byte("chunk")
+		}
+	}()
+
+	res, err := StreamUpload(c, "http://example.com/upload", chunks)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	res.Body.Close()
+
+	select {
+	case <-produced:
+	case <-time.After(time.Second):
+		t.Fatal("expected the producer to be unblocked once the response was closed")
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+// ErrUnexpectedRequest is returned by a strict mock for requests it didn't expect
+var ErrUnexpectedRequest = errors.New("unexpected request")
+
+// ExpectedRequest is a request a strict mock expects to receive, along with the response to give it
+type ExpectedRequest struct {
+	// Method is matched if set
+	Method string
+
+	// URL is matched against either the full request URL or just its path
+	URL string
+
+	// StatusCode and Body make up the response, StatusCode defaults to 200
+	StatusCode int
+	Body       string
+}
+
+func (e ExpectedRequest) matches(req *http.Request) bool {
+	if e.Method != "" && e.Method != req.Method {
+		return false
+	}
+	return e.URL == req.URL.String() || e.URL == req.URL.Path
+}
+
+func (e ExpectedRequest) String() string {
+	m := e.Method
+	if m == "" {
+		m = "*"
+	}
+	return m + " " + e.URL
+}
+
+// StrictMockHTTPClient returns an HTTPClient which only responds to the expected requests, each of them once
+// When ordered is set the requests also have to arrive in the given order
+// Any other request fails with ErrUnexpectedRequest
+// The returned verify function fails the test if there were any unexpected requests, or any expected requests weren't made
+func StrictMockHTTPClient(expected []ExpectedRequest, ordered bool) (HTTPClient, func(t testing.TB)) {
+	var mu sync.Mutex
+	matched := make([]bool, len(expected))
+	violations := []string{}
+
+	c := &MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			for i, e := range expected {
+				if matched[i] {
+					continue
+				}
+
+				if !e.matches(req) {
+					// in order, only the first outstanding expectation may match
+					if ordered {
+						break
+					}
+					continue
+				}
+
+				matched[i] = true
+
+				code := e.StatusCode
+				if code == 0 {
+					code = http.StatusOK
+				}
+				return stringResponse(req, code, e.Body), nil
+			}
+
+			violations = append(violations, fmt.Sprintf("unexpected request: %s %s", req.Method, req.URL))
+			return nil, fmt.Errorf("%w: %s %s", ErrUnexpectedRequest, req.Method, req.URL)
+		},
+	}
+
+	verify := func(t testing.TB) {
+		t.Helper()
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		for _, v := range violations {
+			t.Error(v)
+		}
+
+		for i, e := range expected {
+			if !matched[i] {
+				t.Errorf("expected request was never made: %s", e)
+			}
+		}
+	}
+
+	return c, verify
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// fakeTB records failures instead of failing the actual test
+type fakeTB struct {
+	testing.TB
+	errors []string
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Error(args ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprint(args...))
+}
+
+func (f *fakeTB) Errorf(format string, args ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func TestStrictMockHTTPClient(t *testing.T) {
+	expected := []ExpectedRequest{
+		{Method: "GET", URL: "/users/1", Body: "alice"},
+		{Method: "POST", URL: "/users", StatusCode: 201},
+	}
+
+	tcs := []struct {
+		name           string
+		requests       [][2]string
+		expectedErrors int
+	}{
+		{"all matched", [][2]string{{"GET", "/users/1"}, {"POST", "/users"}}, 0},
+		{"missing request", [][2]string{{"GET", "/users/1"}}, 1},
+		{"extra request", [][2]string{{"GET", "/users/1"}, {"POST", "/users"}, {"DELETE", "/users/1"}}, 1},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			c, verify := StrictMockHTTPClient(expected, false)
+
+			for _, r := range tc.requests {
+				req, _ := http.NewRequest(r[0], "http://example.com"+r[1], nil)
+				_, err := c.Do(req)
+				if r[0] == "DELETE" {
+					if !errors.Is(err, ErrUnexpectedRequest) {
+						t.Fatalf("expected ErrUnexpectedRequest, got %v", err)
+					}
+				} else if err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+			}
+
+			tb := &fakeTB{}
+			verify(tb)
+			if len(tb.errors) != tc.expectedErrors {
+				t.Fatalf("expected %d verification errors, got %q", tc.expectedErrors, tb.errors)
+			}
+		})
+	}
+}
+
+func TestStrictMockHTTPClientOrdered(t *testing.T) {
+	c, verify := StrictMockHTTPClient([]ExpectedRequest{
+		{URL: "/first"},
+		{URL: "/second"},
+	}, true)
+
+	req, _ := http.NewRequest("GET", "http://example.com/second", nil)
+	if _, err := c.Do(req); !errors.Is(err, ErrUnexpectedRequest) {
+		t.Fatalf("expected an out of order request to be rejected, got %v", err)
+	}
+
+	tb := &fakeTB{}
+	verify(tb)
+	if len(tb.errors) != 3 {
+		t.Fatalf("expected the violation and both missing requests to be reported, got %q", tb.errors)
+	}
+}
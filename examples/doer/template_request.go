@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var placeholderRe = regexp.MustCompile(`\{([A-Za-z0-9_]+)\}`)
+
+// TemplateRequest builds a request after expanding `{var}` placeholders in urlTmpl with the given vars
+// Values are path-escaped before being substituted
+// Any placeholder without a matching var results in an error
+func TemplateRequest(method, urlTmpl string, vars map[string]string, body io.Reader) (*http.Request, error) {
+	missing := []string{}
+
+	u := placeholderRe.ReplaceAllStringFunc(urlTmpl, func(m string) string {
+		name := m[1 : len(m)-1]
+
+		v, ok := vars[name]
+		if !ok {
+			missing = append(missing, name)
+			return m
+		}
+
+		return url.PathEscape(v)
+	})
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("unresolved placeholders in url template %q: %s", urlTmpl, strings.Join(missing, ", "))
+	}
+
+	return http.NewRequest(method, u, body)
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTemplateRequest(t *testing.T) {
+	req, err := TemplateRequest("GET", "http://example.com/users/{id}/posts/{slug}", map[string]string{
+		"id":   "42",
+		"slug": "hello world",
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if got, expected := req.URL.String(), "http://example.com/users/42/posts/hello%20world"; got != expected {
+		t.Fatalf("expected %s, got %s", expected, got)
+	}
+}
+
+func TestTemplateRequestMissingVar(t *testing.T) {
+	_, err := TemplateRequest("GET", "http://example.com/users/{id}/posts/{slug}", map[string]string{
+		"id": "42",
+	}, nil)
+	if err == nil || !strings.Contains(err.Error(), "slug") {
+		t.Fatalf("expected an error naming the missing var, got %v", err)
+	}
+}
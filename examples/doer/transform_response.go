@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// TransformResponseHTTPClient buffers each response body and replaces it with the result of the given transform
+// An error from the transform is returned instead of the response
+func TransformResponseHTTPClient(c HTTPClient, tfn func([]byte) ([]byte, error)) HTTPClient {
+	return &MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			res, err := c.Do(req)
+			if err != nil {
+				return nil, err
+			}
+
+			var body []byte
+			if res.Body != nil {
+				body, err = io.ReadAll(res.Body)
+				res.Body.Close()
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			body, err = tfn(body)
+			if err != nil {
+				return nil, err
+			}
+
+			// the body length has most likely changed
+			res.Body = io.NopCloser(bytes.NewReader(body))
+			res.ContentLength = int64(len(body))
+			if res.Header != nil {
+				res.Header.Set("Content-Length", strconv.Itoa(len(body)))
+			}
+
+			return res, nil
+		},
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestTransformResponseHTTPClient(t *testing.T) {
+	base := &MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			return stringResponse(req, 200, "hello"), nil
+		},
+	}
+
+	t.Run("uppercase", func(t *testing.T) {
+		c := TransformResponseHTTPClient(base, func(bs []byte) ([]byte, error) {
+			return bytes.ToUpper(bs), nil
+		})
+
+		req, _ := http.NewRequest("GET", "http://example.com", nil)
+		res, err := c.Do(req)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		bs, _ := io.ReadAll(res.Body)
+		if string(bs) != "HELLO" {
+			t.Fatalf("expected transformed body, got %q", bs)
+		}
+		if res.ContentLength != 5 {
+			t.Fatalf("expected content length 5, got %d", res.ContentLength)
+		}
+	})
+
+	t.Run("failing transform", func(t *testing.T) {
+		errTransform := errors.New("bad body")
+		c := TransformResponseHTTPClient(base, func(bs []byte) ([]byte, error) {
+			return nil, errTransform
+		})
+
+		req, _ := http.NewRequest("GET", "http://example.com", nil)
+		res, err := c.Do(req)
+		if !errors.Is(err, errTransform) {
+			t.Fatalf("expected transform error, got %v", err)
+		}
+		if res != nil {
+			t.Fatalf("expected no response, got %v", res)
+		}
+	})
+}
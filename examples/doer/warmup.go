@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// WarmingHTTPClient is an HTTPClient which can be warmed up ahead of real traffic
+type WarmingHTTPClient interface {
+	HTTPClient
+	Warmup(ctx context.Context) error
+}
+
+type warmupHTTPClient struct {
+	HTTPClient
+	urls []string
+}
+
+// WarmupHTTPClient wraps an HTTPClient so it can be warmed up by sending HEAD requests to the given URLs,
+// priming DNS and connection pools to reduce latency for the first real requests
+func WarmupHTTPClient(c HTTPClient, urls []string) WarmingHTTPClient {
+	return &warmupHTTPClient{
+		HTTPClient: c,
+		urls:       urls,
+	}
+}
+
+// Warmup sends a HEAD request to each URL concurrently
+// Individual failures are tolerated, an error is only returned if all of them fail
+func (c *warmupHTTPClient) Warmup(ctx context.Context) error {
+	errs := make([]error, len(c.urls))
+
+	var wg sync.WaitGroup
+	for i, u := range c.urls {
+		wg.Add(1)
+		go func(i int, u string) {
+			defer wg.Done()
+			errs[i] = c.warm(ctx, u)
+		}(i, u)
+	}
+	wg.Wait()
+
+	failed := []error{}
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+
+	if len(c.urls) > 0 && len(failed) == len(c.urls) {
+		return fmt.Errorf("warmup failed for all urls: %w", errors.Join(failed...))
+	}
+
+	return nil
+}
+
+func (c *warmupHTTPClient) warm(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, "HEAD", url, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := c.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", url, err)
+	}
+
+	// drain the body so the connection can be reused
+	if res.Body != nil {
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+	}
+
+	return nil
+}
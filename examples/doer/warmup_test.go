@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestWarmupHTTPClient(t *testing.T) {
+	var mu sync.Mutex
+	warmed := []string{}
+
+	c := WarmupHTTPClient(&MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			warmed = append(warmed, req.Method+" "+req.URL.String())
+
+			if req.URL.Host == "down.example.com" {
+				return nil, errors.New("connection refused")
+			}
+			return stringResponse(req, 200, ""), nil
+		},
+	}, []string{"http://a.example.com", "http://b.example.com", "http://down.example.com"})
+
+	// a single failure is tolerated
+	if err := c.Warmup(context.Background()); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	sort.Strings(warmed)
+	expected := []string{"HEAD http://a.example.com", "HEAD http://b.example.com", "HEAD http://down.example.com"}
+	for i := range expected {
+		if warmed[i] != expected[i] {
+			t.Fatalf("expected %q, got %q", expected, warmed)
+		}
+	}
+}
+
+func TestWarmupHTTPClientAllFail(t *testing.T) {
+	c := WarmupHTTPClient(&MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			return nil, errors.New("connection refused")
+		},
+	}, []string{"http://a.example.com", "http://b.example.com"})
+
+	if err := c.Warmup(context.Background()); err == nil {
+		t.Fatal("expected an error when all urls fail")
+	}
+}
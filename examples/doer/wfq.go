@@ -0,0 +1,167 @@
+package main
+
+import (
+	"container/heap"
+	"context"
+	"net/http"
+	"sync"
+)
+
+type priorityClassKey struct{}
+
+// WithPriorityClass returns a context which assigns requests made with it to the given priority class
+func WithPriorityClass(ctx context.Context, class string) context.Context {
+	return context.WithValue(ctx, priorityClassKey{}, class)
+}
+
+// priorityClass returns the priority class of a request with the given context, the default class is ""
+func priorityClass(ctx context.Context) string {
+	class, _ := ctx.Value(priorityClassKey{}).(string)
+	return class
+}
+
+type wfqWaiter struct {
+	tag       float64
+	seq       uint64
+	ready     chan struct{}
+	cancelled bool
+}
+
+// wfqQueue is a min-heap of waiting requests ordered by their virtual finish tag
+type wfqQueue []*wfqWaiter
+
+func (q wfqQueue) Len() int { return len(q) }
+
+func (q wfqQueue) Less(i, j int) bool {
+	if q[i].tag == q[j].tag {
+		return q[i].seq < q[j].seq
+	}
+	return q[i].tag < q[j].tag
+}
+
+func (q wfqQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *wfqQueue) Push(x interface{}) { *q = append(*q, x.(*wfqWaiter)) }
+
+func (q *wfqQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	w := old[n-1]
+	*q = old[:n-1]
+	return w
+}
+
+type wfqScheduler struct {
+	mu          sync.Mutex
+	maxInFlight int
+	inflight    int
+	weights     map[string]int
+
+	// virtual time advances with the tags of dispatched requests,
+	// lastFinish holds the tag of each class's most recently queued request
+	vtime      float64
+	lastFinish map[string]float64
+
+	seq     uint64
+	waiting wfqQueue
+}
+
+func (s *wfqScheduler) weight(class string) float64 {
+	if w, ok := s.weights[class]; ok && w > 0 {
+		return float64(w)
+	}
+	return 1
+}
+
+func (s *wfqScheduler) acquire(ctx context.Context) error {
+	s.mu.Lock()
+	if s.inflight < s.maxInFlight && s.waiting.Len() == 0 {
+		s.inflight++
+		s.mu.Unlock()
+		return nil
+	}
+
+	// each request costs 1/weight of virtual time, so heavier classes get through proportionally more often
+	class := priorityClass(ctx)
+	start := s.vtime
+	if s.lastFinish[class] > start {
+		start = s.lastFinish[class]
+	}
+	tag := start + 1/s.weight(class)
+	s.lastFinish[class] = tag
+
+	w := &wfqWaiter{tag: tag, seq: s.seq, ready: make(chan struct{})}
+	s.seq++
+	heap.Push(&s.waiting, w)
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		// we were handed a slot in the meantime, pass it on
+		s.inflight--
+		s.dispatch()
+	default:
+		// skipped once it reaches the front of the queue
+		w.cancelled = true
+	}
+
+	return ctx.Err()
+}
+
+func (s *wfqScheduler) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.inflight--
+	s.dispatch()
+}
+
+// dispatch hands free slots to the waiting requests with the lowest tags, s.mu must be held
+func (s *wfqScheduler) dispatch() {
+	for s.inflight < s.maxInFlight && s.waiting.Len() > 0 {
+		w := heap.Pop(&s.waiting).(*wfqWaiter)
+		if w.cancelled {
+			continue
+		}
+
+		s.inflight++
+		s.vtime = w.tag
+		close(w.ready)
+	}
+}
+
+// WFQHTTPClient limits the number of concurrent requests to the given HTTPClient to maxInFlight
+// When saturated, slots are shared between priority classes (see WithPriorityClass) in proportion to their weights
+// (weighted fair queuing), so lower priority classes still make progress rather than being starved
+// Classes missing from weights have a weight of 1
+func WFQHTTPClient(c HTTPClient, maxInFlight int, weights map[string]int) HTTPClient {
+	if maxInFlight < 1 {
+		maxInFlight = 1
+	}
+
+	s := &wfqScheduler{
+		maxInFlight: maxInFlight,
+		weights:     weights,
+		lastFinish:  map[string]float64{},
+	}
+
+	return &MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			if err := s.acquire(req.Context()); err != nil {
+				return nil, err
+			}
+			defer s.release()
+
+			return c.Do(req)
+		},
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWFQHTTPClient(t *testing.T) {
+	var mu sync.Mutex
+	order := []string{}
+	blocker := make(chan struct{})
+
+	c := WFQHTTPClient(&MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			class := priorityClass(req.Context())
+			if class == "blocker" {
+				<-blocker
+			} else {
+				mu.Lock()
+				order = append(order, class)
+				mu.Unlock()
+			}
+			return stringResponse(req, 200, "ok"), nil
+		},
+	}, 1, map[string]int{"high": 3, "low": 1})
+
+	do := func(class string) {
+		req, _ := http.NewRequestWithContext(WithPriorityClass(context.Background(), class), "GET", "http://example.com", nil)
+		if _, err := c.Do(req); err != nil {
+			t.Errorf("unexpected error %v", err)
+		}
+	}
+
+	// occupy the only slot, so everything else has to queue up
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		do("blocker")
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	for i := 0; i < 30; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			do("high")
+		}()
+		go func() {
+			defer wg.Done()
+			do("low")
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	close(blocker)
+	wg.Wait()
+
+	// while both classes are waiting, high gets 3 slots for every one low gets
+	high := 0
+	for _, class := range order[:20] {
+		if class == "high" {
+			high++
+		}
+	}
+	if high < 14 || high > 16 {
+		t.Fatalf("expected ~15 of the first 20 slots to go to the high class, got %d: %v", high, order[:20])
+	}
+}
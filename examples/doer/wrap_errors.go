@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WrapErrorsHTTPClient adds the request method, URL and elapsed time to any error returned by the given HTTPClient
+// The original error is preserved, so errors.Is and errors.As keep working
+func WrapErrorsHTTPClient(c HTTPClient) HTTPClient {
+	return &MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+
+			res, err := c.Do(req)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s failed after %s: %w", req.Method, req.URL, time.Since(start), err)
+			}
+
+			return res, nil
+		},
+	}
+}
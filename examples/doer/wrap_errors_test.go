@@ -0,0 +1,36 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestWrapErrorsHTTPClient(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	c := WrapErrorsHTTPClient(&MockHTTPClient{
+		DoFn: func(req *http.Request) (*http.Response, error) {
+			return nil, errBoom
+		},
+	})
+
+	req, err := http.NewRequest("GET", "http://example.com/path", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.Do(req)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if !strings.Contains(err.Error(), "GET http://example.com/path failed after") {
+		t.Fatalf("expected error to contain the request, got %q", err)
+	}
+
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("expected error to wrap the original error, got %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// AttributeSamplePublisher sends a sample of messages to the given Publisher,
+// at a rate (0-1) that depends on the message attribute derived by attrFn, e.g keep all errors but only 1% of info logs
+// Messages with an attribute missing from rates are always sent
+func AttributeSamplePublisher(p Publisher, attrFn func(msg string) string, rates map[string]float64) Publisher {
+	return AttributeSamplePublisherWithRand(p, attrFn, rates, 1, rand.Float64)
+}
+
+// AttributeSamplePublisherWithRand is the same as AttributeSamplePublisher,
+// but samples messages with a missing attribute at defaultRate, and uses randFn (returning values in [0, 1)) to sample
+func AttributeSamplePublisherWithRand(p Publisher, attrFn func(msg string) string, rates map[string]float64, defaultRate float64, randFn func() float64) Publisher {
+	// randFn may not be safe for concurrent use (e.g a rand.Rand method)
+	var mu sync.Mutex
+
+	return &MockPublisher{
+		PublishFn: func(msg string) error {
+			rate, ok := rates[attrFn(msg)]
+			if !ok {
+				rate = defaultRate
+			}
+
+			mu.Lock()
+			r := randFn()
+			mu.Unlock()
+
+			// dropped messages aren't an error
+			if r >= rate {
+				return nil
+			}
+
+			return p.Publish(msg)
+		},
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAttributeSamplePublisher(t *testing.T) {
+	// cycles through 0.0, 0.1, ..., 0.9
+	i := 0
+	randFn := func() float64 {
+		r := float64(i%10) / 10
+		i++
+		return r
+	}
+
+	p := &collectingPublisher{}
+	attrFn := func(msg string) string { return strings.SplitN(msg, ":", 2)[0] }
+	sp := AttributeSamplePublisherWithRand(p, attrFn, map[string]float64{"error": 1, "info": 0.3}, 0.5, randFn)
+
+	// each level draws a full cycle of random values
+	for _, level := range []string{"error", "info", "debug"} {
+		for n := 0; n < 10; n++ {
+			if err := sp.Publish(level + ":msg"); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+		}
+	}
+
+	counts := map[string]int{}
+	for _, msg := range p.Messages() {
+		counts[attrFn(msg)]++
+	}
+
+	expected := map[string]int{"error": 10, "info": 3, "debug": 5}
+	for level, n := range expected {
+		if counts[level] != n {
+			t.Fatalf("expected %d %s messages to be sampled, got %d", n, level, counts[level])
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+// BackpressurePublisher queues messages and publishes them to the given Publisher in the background
+// When the number of pending messages reaches highWater, true is sent on the returned channel to ask producers to slow down,
+// once it drops back to lowWater false is sent, signals don't repeat in between so they don't flap
+// If no one is reading the channel, older signals are dropped in favor of newer ones
+// Errors from the wrapped Publisher have no one to be reported to and are dropped
+// Closing the returned Publisher publishes any remaining messages and then closes the channel,
+// the channel is closed once they're out even if the Close context is done first
+func BackpressurePublisher(p Publisher, highWater, lowWater int) (Publisher, <-chan bool) {
+	signals := make(chan bool, 1)
+
+	var mu sync.Mutex
+	cond := sync.NewCond(&mu)
+	queue := []string{}
+	pending := 0
+	congested := false
+	closed := false
+	done := make(chan struct{})
+
+	// signal replaces any unread signal with the latest one, mu must be held
+	signal := func(v bool) {
+		select {
+		case <-signals:
+		default:
+		}
+		signals <- v
+	}
+
+	// check emits a signal when crossing the water marks, mu must be held
+	check := func() {
+		if !congested && pending >= highWater {
+			congested = true
+			signal(true)
+		} else if congested && pending <= lowWater {
+			congested = false
+			signal(false)
+		}
+	}
+
+	go func() {
+		defer close(done)
+
+		// nothing signals once the queue is drained after closing, even if Close gave up waiting
+		defer close(signals)
+
+		for {
+			mu.Lock()
+			for len(queue) == 0 && !closed {
+				cond.Wait()
+			}
+			if len(queue) == 0 {
+				mu.Unlock()
+				return
+			}
+
+			msg := queue[0]
+			queue = queue[1:]
+			mu.Unlock()
+
+			p.Publish(msg)
+
+			mu.Lock()
+			pending--
+			check()
+			mu.Unlock()
+		}
+	}()
+
+	return &MockPublisherCloser{
+		PublishFn: func(msg string) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if closed {
+				return ErrPublisherClosed
+			}
+
+			queue = append(queue, msg)
+			pending++
+			check()
+			cond.Signal()
+
+			return nil
+		},
+		CloseFn: func(ctx context.Context) error {
+			mu.Lock()
+			if closed {
+				mu.Unlock()
+				return ErrPublisherClosed
+			}
+			closed = true
+			cond.Signal()
+			mu.Unlock()
+
+			select {
+			case <-done:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+
+			return nil
+		},
+	}, signals
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// gatedPublisher reports each message as it starts publishing it and then blocks until the test releases it
+func gatedPublisher(started chan<- string, release <-chan struct{}) Publisher {
+	return &MockPublisher{
+		PublishFn: func(msg string) error {
+			started <- msg
+			<-release
+			return nil
+		},
+	}
+}
+
+func expectNoSignal(t *testing.T, signals <-chan bool) {
+	t.Helper()
+
+	select {
+	case v := <-signals:
+		t.Fatalf("expected no signal, got %v", v)
+	default:
+	}
+}
+
+func TestBackpressurePublisherHysteresis(t *testing.T) {
+	started, release := make(chan string), make(chan struct{})
+	bp, signals := BackpressurePublisher(gatedPublisher(started, release), 3, 1)
+
+	// next releases the message in flight and waits for the worker to move on, so pending is settled
+	next := func(want string) {
+		t.Helper()
+
+		release <- struct{}{}
+		if msg := <-started; msg != want {
+			t.Fatalf("expected %q to be published next, got %q", want, msg)
+		}
+	}
+
+	for _, msg := range []string{"a", "b", "c"} {
+		if err := bp.Publish(msg); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+
+	if v := <-signals; !v {
+		t.Fatalf("expected true once pending reaches the high mark")
+	}
+
+	// a is done, 2 pending
+	<-started
+	next("b")
+	expectNoSignal(t, signals)
+
+	// back at the high mark while still congested shouldn't repeat the signal
+	if err := bp.Publish("d"); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	expectNoSignal(t, signals)
+
+	// b is done, 2 pending, still above the low mark
+	next("c")
+	expectNoSignal(t, signals)
+
+	// c is done, 1 pending
+	next("d")
+	if v := <-signals; v {
+		t.Fatalf("expected false once pending drops to the low mark")
+	}
+
+	close(release)
+	if err := bp.(PublisherCloser).Close(context.Background()); err != nil {
+		t.Fatalf("unexpected error on close %v", err)
+	}
+	if _, ok := <-signals; ok {
+		t.Fatalf("expected signals to be closed")
+	}
+}
+
+func TestBackpressurePublisherCloseTimeout(t *testing.T) {
+	started, release := make(chan string, 1), make(chan struct{})
+	bp, signals := BackpressurePublisher(gatedPublisher(started, release), 10, 5)
+
+	if err := bp.Publish("a"); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := bp.(PublisherCloser).Close(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	// signals is still closed once the worker gets through the remaining messages
+	close(release)
+	select {
+	case _, ok := <-signals:
+		if ok {
+			t.Fatalf("expected signals to be closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected signals to be closed after the worker exits")
+	}
+}
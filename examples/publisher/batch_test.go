@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestBatchPublisherConsecutiveBatches(t *testing.T) {
+	p := &collectingPublisher{}
+	bp := BatchPublisher(p, 2)
+
+	for _, msg := range []string{"a", "b", "c", "d", "e"} {
+		if err := bp.Publish(msg); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+
+	// each batch only holds its own messages
+	if msgs, want := p.Messages(), []string{"a,b", "c,d"}; !reflect.DeepEqual(msgs, want) {
+		t.Fatalf("expected %q, got %q", want, msgs)
+	}
+
+	if err := bp.(PublisherFlusher).Flush(context.Background(), nil); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if msgs, want := p.Messages(), []string{"a,b", "c,d", "e"}; !reflect.DeepEqual(msgs, want) {
+		t.Fatalf("expected %q after flushing, got %q", want, msgs)
+	}
+}
+
+func TestBatchPublisherClose(t *testing.T) {
+	p := &collectingPublisher{}
+	bp := BatchPublisher(p, 2)
+
+	bp.Publish("a")
+	if err := bp.(PublisherCloser).Close(context.Background()); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if msgs := p.Messages(); !reflect.DeepEqual(msgs, []string{"a"}) {
+		t.Fatalf("expected the partial batch to be sent on close, got %q", msgs)
+	}
+
+	if err := bp.Publish("b"); err != ErrPublisherClosed {
+		t.Fatalf("expected ErrPublisherClosed, got %v", err)
+	}
+	if err := bp.(PublisherCloser).Close(context.Background()); err != ErrPublisherClosed {
+		t.Fatalf("expected ErrPublisherClosed on a second close, got %v", err)
+	}
+}
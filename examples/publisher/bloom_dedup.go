@@ -0,0 +1,121 @@
+package main
+
+import (
+	"hash/fnv"
+	"math"
+	"sync"
+)
+
+// bloomFilter is a fixed-size probabilistic set, it may report false positives but never false negatives
+type bloomFilter struct {
+	bits []uint64
+	m    uint64
+	k    uint64
+}
+
+// newBloomFilter sizes a filter to hold n items with the given false positive rate
+func newBloomFilter(n int, fpRate float64) *bloomFilter {
+	if n < 1 {
+		n = 1
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		fpRate = 0.01
+	}
+
+	// optimal number of bits and hash functions
+	m := uint64(math.Ceil(-float64(n) * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	k := uint64(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+
+	return &bloomFilter{
+		bits: make([]uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+	}
+}
+
+// positions derives the k bit positions of s using double hashing
+func (f *bloomFilter) positions(s string) []uint64 {
+	h1 := fnv.New64a()
+	h1.Write([]byte(s))
+	a := h1.Sum64()
+
+	h2 := fnv.New64()
+	h2.Write([]byte(s))
+	b := h2.Sum64() | 1
+
+	ps := make([]uint64, f.k)
+	for i := range ps {
+		ps[i] = (a + uint64(i)*b) % f.m
+	}
+	return ps
+}
+
+func (f *bloomFilter) add(s string) {
+	for _, p := range f.positions(s) {
+		f.bits[p/64] |= 1 << (p % 64)
+	}
+}
+
+func (f *bloomFilter) has(s string) bool {
+	for _, p := range f.positions(s) {
+		if f.bits[p/64]&(1<<(p%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// BloomDedup is a Publisher which drops duplicate messages using a bloom filter
+type BloomDedup struct {
+	p             Publisher
+	expectedItems int
+	fpRate        float64
+
+	mu     sync.Mutex
+	filter *bloomFilter
+}
+
+// BloomDedupPublisher drops messages which were already published to the given Publisher
+// Memory use is fixed, based on expectedItems and falsePositiveRate, regardless of how many messages go through
+// The tradeoff is that roughly falsePositiveRate of new messages are wrongly considered duplicates and dropped,
+// and that rate grows once more than expectedItems messages were seen, so Reset the filter periodically
+func BloomDedupPublisher(p Publisher, expectedItems int, falsePositiveRate float64) *BloomDedup {
+	return &BloomDedup{
+		p:             p,
+		expectedItems: expectedItems,
+		fpRate:        falsePositiveRate,
+		filter:        newBloomFilter(expectedItems, falsePositiveRate),
+	}
+}
+
+// Publish publishes the message unless it (probably) was already published
+// Messages are only remembered once published successfully
+func (d *BloomDedup) Publish(msg string) error {
+	d.mu.Lock()
+	seen := d.filter.has(msg)
+	d.mu.Unlock()
+
+	if seen {
+		return nil
+	}
+
+	if err := d.p.Publish(msg); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	d.filter.add(msg)
+	d.mu.Unlock()
+
+	return nil
+}
+
+// Reset forgets all previously published messages
+func (d *BloomDedup) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.filter = newBloomFilter(d.expectedItems, d.fpRate)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestBloomDedupPublisher(t *testing.T) {
+	p := &collectingPublisher{}
+	dp := BloomDedupPublisher(p, 100, 0.01)
+
+	for _, msg := range []string{"a", "b", "a", "c", "b"} {
+		if err := dp.Publish(msg); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+	if msgs := p.Messages(); !reflect.DeepEqual(msgs, []string{"a", "b", "c"}) {
+		t.Fatalf("expected duplicates to be dropped, got %q", msgs)
+	}
+
+	dp.Reset()
+	dp.Publish("a")
+	if n := len(p.Messages()); n != 4 {
+		t.Fatalf("expected a reset filter to forget published messages, got %d messages", n)
+	}
+}
+
+func TestBloomDedupPublisherBoundedMemory(t *testing.T) {
+	p := &collectingPublisher{}
+	dp := BloomDedupPublisher(p, 1000, 0.01)
+	size := len(dp.filter.bits)
+
+	for i := 0; i < 1000; i++ {
+		dp.Publish(fmt.Sprintf("msg-%d", i))
+	}
+
+	// roughly 1% of new messages may be wrongly dropped
+	if n := len(p.Messages()); n < 970 {
+		t.Fatalf("expected about 99%% of unique messages to be published, got %d of 1000", n)
+	}
+
+	for i := 1000; i < 10000; i++ {
+		dp.Publish(fmt.Sprintf("msg-%d", i))
+	}
+	if len(dp.filter.bits) != size {
+		t.Fatalf("expected the filter to stay at %d words, got %d", size, len(dp.filter.bits))
+	}
+}
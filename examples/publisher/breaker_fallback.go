@@ -0,0 +1,57 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// BreakerFallbackPublisher sends messages to the given Publisher until it fails `threshold` times in a row
+// at which point the breaker opens and messages are sent to the fallback Publisher instead
+// Messages which fail to publish to the primary Publisher are sent to the fallback Publisher as well
+// Once `cooldown` has passed a single message is used to probe the primary Publisher again,
+// on success the breaker closes, on failure it stays open for another cooldown period
+func BreakerFallbackPublisher(p, fallback Publisher, threshold int, cooldown time.Duration) Publisher {
+	var mu sync.Mutex
+	failures := 0
+	var openUntil time.Time
+	probing := false
+
+	return &MockPublisher{
+		PublishFn: func(msg string) error {
+			mu.Lock()
+			open := failures >= threshold
+			probe := false
+			if open && !probing && !time.Now().Before(openUntil) {
+				probing, probe = true, true
+			}
+			mu.Unlock()
+
+			// everyone else keeps using the fallback while the probe is in flight
+			if open && !probe {
+				return fallback.Publish(msg)
+			}
+
+			err := p.Publish(msg)
+
+			mu.Lock()
+			if probe {
+				probing = false
+			}
+
+			if err == nil {
+				failures = 0
+				mu.Unlock()
+				return nil
+			}
+
+			failures++
+			if failures >= threshold {
+				openUntil = time.Now().Add(cooldown)
+			}
+			mu.Unlock()
+
+			// the message still has to go somewhere
+			return fallback.Publish(msg)
+		},
+	}
+}
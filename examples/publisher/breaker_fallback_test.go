@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBreakerFallbackPublisher(t *testing.T) {
+	primary, fallback := &collectingPublisher{}, &collectingPublisher{}
+	calls := 0
+	p := BreakerFallbackPublisher(&MockPublisher{
+		PublishFn: func(msg string) error {
+			calls++
+			return primary.Publish(msg)
+		},
+	}, fallback, 2, 20*time.Millisecond)
+
+	primary.setErr(errors.New("unavailable"))
+
+	// the second failure trips the breaker, after which the primary isn't tried at all
+	for _, msg := range []string{"a", "b", "c"} {
+		if err := p.Publish(msg); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+	if calls != 2 {
+		t.Fatalf("expected the open breaker to skip the primary, got %d calls", calls)
+	}
+	if msgs := fallback.Messages(); !reflect.DeepEqual(msgs, []string{"a", "b", "c"}) {
+		t.Fatalf("expected all messages to reach the fallback, got %q", msgs)
+	}
+
+	// a failed probe goes to the fallback as well
+	time.Sleep(30 * time.Millisecond)
+	p.Publish("d")
+	p.Publish("e")
+	if calls != 3 {
+		t.Fatalf("expected a single probe, got %d calls", calls)
+	}
+	if msgs := fallback.Messages(); !reflect.DeepEqual(msgs, []string{"a", "b", "c", "d", "e"}) {
+		t.Fatalf("expected the failed probe to reach the fallback, got %q", msgs)
+	}
+
+	// the primary recovers
+	primary.setErr(nil)
+	time.Sleep(30 * time.Millisecond)
+	p.Publish("f")
+	p.Publish("g")
+	if msgs := primary.Messages(); !reflect.DeepEqual(msgs, []string{"f", "g"}) {
+		t.Fatalf("expected the breaker to close after a successful probe, got %q", msgs)
+	}
+}
+
+func TestBreakerFallbackPublisherSingleProbe(t *testing.T) {
+	fallback := &collectingPublisher{}
+
+	fail := true
+	started := make(chan struct{})
+	release := make(chan struct{})
+	p := BreakerFallbackPublisher(&MockPublisher{
+		PublishFn: func(msg string) error {
+			if fail {
+				return errors.New("unavailable")
+			}
+			close(started)
+			<-release
+			return nil
+		},
+	}, fallback, 1, 10*time.Millisecond)
+
+	p.Publish("a")
+	fail = false
+	time.Sleep(20 * time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.Publish("probe")
+	}()
+	<-started
+
+	// the probe is still in flight, so this one must not become a second probe
+	p.Publish("b")
+	close(release)
+	<-done
+
+	if msgs := fallback.Messages(); !reflect.DeepEqual(msgs, []string{"a", "b"}) {
+		t.Fatalf("expected only the probe to reach the primary, got fallback %q", msgs)
+	}
+}
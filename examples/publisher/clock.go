@@ -0,0 +1,19 @@
+package main
+
+import (
+	"time"
+)
+
+// Clock abstracts time so time-dependent publishers can be tested deterministically
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time        { return time.Now() }
+func (realClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+// RealClock is a Clock backed by the time package
+var RealClock Clock = realClock{}
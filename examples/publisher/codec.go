@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+)
+
+// Codec converts values to and from message strings
+type Codec interface {
+	Encode(v any) (string, error)
+	Decode(s string, v any) error
+}
+
+// JSONCodec is a Codec using JSON encoding
+type JSONCodec struct{}
+
+// Encode encodes v as JSON
+func (JSONCodec) Encode(v any) (string, error) {
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(bs), nil
+}
+
+// Decode decodes the JSON in s into v
+func (JSONCodec) Decode(s string, v any) error {
+	return json.Unmarshal([]byte(s), v)
+}
+
+// TypedPublisher publishes values of type T
+type TypedPublisher[T any] interface {
+	Publish(v T) error
+}
+
+// MockTypedPublisher is a mockable TypedPublisher
+type MockTypedPublisher[T any] struct {
+	PublishFn func(v T) error
+}
+
+// Publish calls the underlying Publish method
+func (p *MockTypedPublisher[T]) Publish(v T) error {
+	return p.PublishFn(v)
+}
+
+// CodecPublisher encodes values using the given Codec and sends them to the given Publisher
+func CodecPublisher[T any](p Publisher, codec Codec) TypedPublisher[T] {
+	return &MockTypedPublisher[T]{
+		PublishFn: func(v T) error {
+			msg, err := codec.Encode(v)
+			if err != nil {
+				return err
+			}
+			return p.Publish(msg)
+		},
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCodecPublisherRoundTrip(t *testing.T) {
+	type event struct {
+		Name string            `json:"name"`
+		Tags map[string]string `json:"tags"`
+	}
+
+	p := &collectingPublisher{}
+	cp := CodecPublisher[event](p, JSONCodec{})
+
+	in := event{Name: "signup", Tags: map[string]string{"plan": "pro"}}
+	if err := cp.Publish(in); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	msgs := p.Messages()
+	if len(msgs) != 1 {
+		t.Fatalf("expected 1 message, got %q", msgs)
+	}
+
+	var out event
+	if err := (JSONCodec{}).Decode(msgs[0], &out); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Fatalf("expected %+v, got %+v", in, out)
+	}
+}
+
+func TestCodecPublisherEncodeError(t *testing.T) {
+	p := &collectingPublisher{}
+	cp := CodecPublisher[chan int](p, JSONCodec{})
+
+	if err := cp.Publish(make(chan int)); err == nil {
+		t.Fatal("expected an encoding error")
+	}
+	if msgs := p.Messages(); len(msgs) != 0 {
+		t.Fatalf("expected nothing to be published, got %q", msgs)
+	}
+}
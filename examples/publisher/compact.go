@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrMessagesDropped is returned when closing gives up before every buffered message was published
+var ErrMessagesDropped = errors.New("buffered messages were dropped on close")
+
+// CompactPublisher holds on to messages for `flushEvery` and then publishes only the latest message per key (as derived by keyFn)
+// Superseded messages within the same window are discarded, similar to Kafka log compaction
+// Messages are flushed in the order their keys first appeared in the window
+// Flushing the returned Publisher publishes the current window right away,
+// anything not published by the time the context is done is kept for the next window
+// Closing the returned Publisher stops the periodic flushing and flushes whatever is left,
+// if the context is done first the rest is dropped and ErrMessagesDropped is returned
+func CompactPublisher(p Publisher, keyFn func(msg string) string, flushEvery time.Duration) Publisher {
+	var mu sync.Mutex
+	keys := []string{}
+	latest := map[string]string{}
+	closed := false
+
+	// flushMu serializes flushes, so an older value for a key can't be published after a newer one
+	var flushMu sync.Mutex
+
+	// requeue puts back messages which weren't flushed, ahead of anything newer,
+	// unless a newer message with the same key arrived in the meantime
+	requeue := func(ks []string, msgs map[string]string) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		rest := []string{}
+		for _, k := range ks {
+			if _, ok := latest[k]; ok {
+				continue
+			}
+			latest[k] = msgs[k]
+			rest = append(rest, k)
+		}
+		keys = append(rest, keys...)
+	}
+
+	flush := func(ctx context.Context, progress ProgressFunc) error {
+		flushMu.Lock()
+		defer flushMu.Unlock()
+
+		mu.Lock()
+		ks, msgs := keys, latest
+		keys, latest = []string{}, map[string]string{}
+		mu.Unlock()
+
+		progress(len(ks))
+
+		errs := []error{}
+		for i, k := range ks {
+			if err := ctx.Err(); err != nil {
+				requeue(ks[i:], msgs)
+				errs = append(errs, err)
+				break
+			}
+
+			if err := p.Publish(msgs[k]); err != nil {
+				errs = append(errs, err)
+			}
+			progress(len(ks) - i - 1)
+		}
+		return errors.Join(errs...)
+	}
+
+	noProgress := func(remaining int) {}
+
+	quit := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		t := time.NewTicker(flushEvery)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-t.C:
+				// there's no one to report errors to in the background
+				flush(context.Background(), noProgress)
+			case <-quit:
+				return
+			}
+		}
+	}()
+
+	var closeOnce sync.Once
+
+	return &MockBufferedPublisher{
+		PublishFn: func(msg string) error {
+			k := keyFn(msg)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if closed {
+				return ErrPublisherClosed
+			}
+
+			if _, ok := latest[k]; !ok {
+				keys = append(keys, k)
+			}
+			latest[k] = msg
+
+			return nil
+		},
+		FlushFn: func(ctx context.Context, progress ProgressFunc) error {
+			return flush(ctx, progress)
+		},
+		CloseFn: func(ctx context.Context) error {
+			err := ErrPublisherClosed
+			closeOnce.Do(func() {
+				mu.Lock()
+				closed = true
+				mu.Unlock()
+
+				close(quit)
+				<-done
+				err = flush(ctx, noProgress)
+
+				// nothing will flush again, so whatever was put back is lost
+				mu.Lock()
+				defer mu.Unlock()
+
+				if n := len(keys); n > 0 {
+					keys, latest = []string{}, map[string]string{}
+					err = fmt.Errorf("%w: %d messages: %w", ErrMessagesDropped, n, err)
+				}
+			})
+			return err
+		},
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompactPublisher(t *testing.T) {
+	p := &collectingPublisher{}
+	keyFn := func(msg string) string { return strings.SplitN(msg, "=", 2)[0] }
+
+	cp := CompactPublisher(p, keyFn, time.Hour)
+	for _, msg := range []string{"a=1", "b=1", "a=2", "a=3", "b=2", "c=1"} {
+		if err := cp.Publish(msg); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+
+	if err := cp.(PublisherFlusher).Flush(context.Background(), nil); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// keys keep the order in which they first appeared
+	expected := []string{"a=3", "b=2", "c=1"}
+	if msgs := p.Messages(); !reflect.DeepEqual(msgs, expected) {
+		t.Fatalf("expected %q, got %q", expected, msgs)
+	}
+
+	if err := cp.(PublisherCloser).Close(context.Background()); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := cp.Publish("a=4"); err != ErrPublisherClosed {
+		t.Fatalf("expected ErrPublisherClosed, got %v", err)
+	}
+}
+
+func TestCompactPublisherPeriodicFlush(t *testing.T) {
+	p := &collectingPublisher{}
+	cp := CompactPublisher(p, func(msg string) string { return "key" }, 10*time.Millisecond)
+	defer cp.(PublisherCloser).Close(context.Background())
+
+	cp.Publish("1")
+	cp.Publish("2")
+
+	deadline := time.Now().Add(time.Second)
+	for len(p.Messages()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	if msgs := p.Messages(); !reflect.DeepEqual(msgs, []string{"2"}) {
+		t.Fatalf("expected only the latest message, got %q", msgs)
+	}
+}
+
+func TestCompactPublisherSerialFlushes(t *testing.T) {
+	started, release := make(chan string), make(chan struct{})
+	cp := CompactPublisher(gatedPublisher(started, release), func(msg string) string { return "key" }, time.Hour)
+	fp := cp.(PublisherFlusher)
+
+	cp.Publish("1")
+	go fp.Flush(context.Background(), nil)
+	<-started
+
+	// a second flush mustn't get to the newer value while the first is still publishing the older one
+	cp.Publish("2")
+	go fp.Flush(context.Background(), nil)
+
+	time.Sleep(20 * time.Millisecond)
+	select {
+	case msg := <-started:
+		t.Fatalf("expected the second flush to wait for the first, but it published %q", msg)
+	default:
+	}
+
+	release <- struct{}{}
+	if msg := <-started; msg != "2" {
+		t.Fatalf("expected 2 to be published next, got %q", msg)
+	}
+	release <- struct{}{}
+}
+
+func TestCompactPublisherCloseDrops(t *testing.T) {
+	p := &collectingPublisher{}
+	cp := CompactPublisher(p, func(msg string) string { return msg }, time.Hour)
+
+	cp.Publish("a")
+	cp.Publish("b")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := cp.(PublisherCloser).Close(ctx)
+	if !errors.Is(err, ErrMessagesDropped) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected ErrMessagesDropped and context.Canceled, got %v", err)
+	}
+	if msgs := p.Messages(); len(msgs) != 0 {
+		t.Fatalf("expected nothing to be published, got %q", msgs)
+	}
+}
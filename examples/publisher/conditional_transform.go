@@ -0,0 +1,12 @@
+package main
+
+// ConditionalTransformPublisher wraps a given Publisher with a message TransformFunc
+// which is only applied to messages matching the `when` predicate, other messages are sent along unchanged
+func ConditionalTransformPublisher(p Publisher, when func(msg string) bool, tfn TransformFunc) Publisher {
+	return TransformPublisher(p, func(msg string) string {
+		if !when(msg) {
+			return msg
+		}
+		return tfn(msg)
+	})
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConditionalTransformPublisher(t *testing.T) {
+	cp := &collectingPublisher{}
+
+	p := ConditionalTransformPublisher(cp,
+		func(msg string) bool { return strings.HasPrefix(msg, "shout:") },
+		strings.ToUpper,
+	)
+
+	for _, msg := range []string{"shout:hello", "hello"} {
+		if err := p.Publish(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := []string{"SHOUT:HELLO", "hello"}
+	if got := cp.Messages(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrPublishDeadline is returned when a message could not be published before its deadline
+var ErrPublishDeadline = errors.New("failed to publish msg before deadline")
+
+// deadlineRetryInterval is how long DeadlinePublisher waits between attempts
+const deadlineRetryInterval = 50 * time.Millisecond
+
+// DeadlinePublisher keeps retrying to publish each message until it succeeds or `perMessage` time has passed
+func DeadlinePublisher(p Publisher, perMessage time.Duration) Publisher {
+	return DeadlinePublisherWithClock(p, perMessage, RealClock)
+}
+
+// DeadlinePublisherWithClock is the same as DeadlinePublisher, but uses the given Clock to keep time
+func DeadlinePublisherWithClock(p Publisher, perMessage time.Duration, clock Clock) Publisher {
+	return &MockPublisher{
+		PublishFn: func(msg string) error {
+			deadline := clock.Now().Add(perMessage)
+
+			for {
+				err := p.Publish(msg)
+				if err == nil {
+					return nil
+				}
+
+				remaining := deadline.Sub(clock.Now())
+				if remaining <= 0 {
+					return fmt.Errorf("%w: %w", ErrPublishDeadline, err)
+				}
+
+				// don't sleep past the deadline, we still want one last attempt
+				wait := deadlineRetryInterval
+				if remaining < wait {
+					wait = remaining
+				}
+				clock.Sleep(wait)
+			}
+		},
+	}
+}
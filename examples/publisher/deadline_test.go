@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock which only moves forward when slept on
+type fakeClock struct {
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.sleeps = append(c.sleeps, d)
+	c.now = c.now.Add(d)
+}
+
+func TestDeadlinePublisher(t *testing.T) {
+	errUnavailable := errors.New("unavailable")
+
+	t.Run("succeeds before deadline", func(t *testing.T) {
+		clock := &fakeClock{now: time.Unix(0, 0)}
+		calls := 0
+		p := DeadlinePublisherWithClock(&MockPublisher{
+			PublishFn: func(msg string) error {
+				calls++
+				if calls < 3 {
+					return errUnavailable
+				}
+				return nil
+			},
+		}, time.Second, clock)
+
+		if err := p.Publish("msg"); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if calls != 3 {
+			t.Fatalf("expected 3 attempts, got %d", calls)
+		}
+	})
+
+	t.Run("gives up at deadline", func(t *testing.T) {
+		clock := &fakeClock{now: time.Unix(0, 0)}
+		calls := 0
+		p := DeadlinePublisherWithClock(&MockPublisher{
+			PublishFn: func(msg string) error {
+				calls++
+				return errUnavailable
+			},
+		}, 120*time.Millisecond, clock)
+
+		err := p.Publish("msg")
+		if !errors.Is(err, ErrPublishDeadline) || !errors.Is(err, errUnavailable) {
+			t.Fatalf("expected a deadline error wrapping the last error, got %v", err)
+		}
+
+		// 50ms, 50ms and then whatever is left, followed by a last attempt
+		expected := []time.Duration{50 * time.Millisecond, 50 * time.Millisecond, 20 * time.Millisecond}
+		if len(clock.sleeps) != len(expected) {
+			t.Fatalf("expected sleeps %v, got %v", expected, clock.sleeps)
+		}
+		for i := range expected {
+			if clock.sleeps[i] != expected[i] {
+				t.Fatalf("expected sleeps %v, got %v", expected, clock.sleeps)
+			}
+		}
+		if calls != 4 {
+			t.Fatalf("expected 4 attempts, got %d", calls)
+		}
+	})
+}
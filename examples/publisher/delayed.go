@@ -0,0 +1,170 @@
+package main
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+// DelayedPublisher holds each message for `delay` before sending it to the given Publisher
+// Messages are released in the order they become due
+// Publishing happens in the background, so errors from the wrapped Publisher are not reported
+// Flushing the returned Publisher sends out the messages being held right away, in due order
+// The returned close function (or closing the Publisher) immediately sends out any messages still being held
+func DelayedPublisher(p Publisher, delay time.Duration) (Publisher, func()) {
+	add := make(chan delayedMsg)
+	flushes := make(chan delayedFlush)
+	quit := make(chan struct{})
+	done := make(chan struct{})
+
+	var mu sync.RWMutex
+	closed := false
+
+	go func() {
+		defer close(done)
+
+		q := &delayQueue{}
+		var seq uint64
+
+		for {
+			// only wait on a timer when there's something to wait for
+			var due <-chan time.Time
+			if q.Len() > 0 {
+				due = time.After(time.Until((*q)[0].due))
+			}
+
+			select {
+			case m := <-add:
+				m.seq = seq
+				seq++
+				heap.Push(q, m)
+
+			case <-due:
+				now := time.Now()
+				for q.Len() > 0 && !(*q)[0].due.After(now) {
+					m := heap.Pop(q).(delayedMsg)
+					p.Publish(m.msg)
+				}
+
+			case f := <-flushes:
+				f.progress(q.Len())
+				for q.Len() > 0 {
+					// whatever we don't get to stays queued until it's due
+					if err := f.ctx.Err(); err != nil {
+						f.result <- err
+						break
+					}
+
+					m := heap.Pop(q).(delayedMsg)
+					p.Publish(m.msg)
+					f.progress(q.Len())
+				}
+				close(f.result)
+
+			case <-quit:
+				// flush whatever is left, in due order
+				for q.Len() > 0 {
+					m := heap.Pop(q).(delayedMsg)
+					p.Publish(m.msg)
+				}
+				return
+			}
+		}
+	}()
+
+	dp := &MockBufferedPublisher{
+		PublishFn: func(msg string) error {
+			mu.RLock()
+			defer mu.RUnlock()
+
+			if closed {
+				return ErrPublisherClosed
+			}
+
+			add <- delayedMsg{msg: msg, due: time.Now().Add(delay)}
+			return nil
+		},
+		FlushFn: func(ctx context.Context, progress ProgressFunc) error {
+			f := delayedFlush{ctx: ctx, progress: progress, result: make(chan error, 1)}
+
+			// the worker is guaranteed to still be running while we're holding the lock
+			mu.RLock()
+			if closed {
+				mu.RUnlock()
+				return ErrPublisherClosed
+			}
+			select {
+			case flushes <- f:
+			case <-ctx.Done():
+				mu.RUnlock()
+				return ctx.Err()
+			}
+			mu.RUnlock()
+
+			return <-f.result
+		},
+		CloseFn: func(ctx context.Context) error {
+			mu.Lock()
+			if closed {
+				mu.Unlock()
+				return ErrPublisherClosed
+			}
+			closed = true
+			close(quit)
+			mu.Unlock()
+
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}
+
+	closeFn := func() {
+		dp.Close(context.Background())
+	}
+
+	return dp, closeFn
+}
+
+// delayedFlush asks the DelayedPublisher worker to send out all held messages
+type delayedFlush struct {
+	ctx      context.Context
+	progress ProgressFunc
+	result   chan error
+}
+
+type delayedMsg struct {
+	msg string
+	due time.Time
+
+	// seq breaks ties between messages which are due at the same time
+	seq uint64
+}
+
+// delayQueue is a min-heap of messages ordered by due time
+type delayQueue []delayedMsg
+
+func (q delayQueue) Len() int { return len(q) }
+
+func (q delayQueue) Less(i, j int) bool {
+	if q[i].due.Equal(q[j].due) {
+		return q[i].seq < q[j].seq
+	}
+	return q[i].due.Before(q[j].due)
+}
+
+func (q delayQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *delayQueue) Push(x interface{}) { *q = append(*q, x.(delayedMsg)) }
+
+func (q *delayQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	m := old[n-1]
+	*q = old[:n-1]
+	return m
+}
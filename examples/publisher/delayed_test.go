@@ -0,0 +1,55 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDelayedPublisher(t *testing.T) {
+	p := &collectingPublisher{}
+	delay := 50 * time.Millisecond
+	dp, closeFn := DelayedPublisher(p, delay)
+	defer closeFn()
+
+	start := time.Now()
+	for _, msg := range []string{"a", "b", "c"} {
+		if err := dp.Publish(msg); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+
+	if msgs := p.Messages(); len(msgs) != 0 {
+		t.Fatalf("expected messages to be held back, got %v", msgs)
+	}
+
+	for len(p.Messages()) < 3 {
+		if time.Since(start) > time.Second {
+			t.Fatalf("timed out waiting for messages, got %v", p.Messages())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if elapsed := time.Since(start); elapsed < delay {
+		t.Fatalf("expected messages to be delayed by at least %s, took %s", delay, elapsed)
+	}
+	if msgs := p.Messages(); !reflect.DeepEqual(msgs, []string{"a", "b", "c"}) {
+		t.Fatalf("expected messages in due order, got %v", msgs)
+	}
+}
+
+func TestDelayedPublisherClose(t *testing.T) {
+	p := &collectingPublisher{}
+	dp, closeFn := DelayedPublisher(p, time.Hour)
+
+	dp.Publish("a")
+	dp.Publish("b")
+	closeFn()
+
+	if msgs := p.Messages(); !reflect.DeepEqual(msgs, []string{"a", "b"}) {
+		t.Fatalf("expected close to send held messages in order, got %v", msgs)
+	}
+	if err := dp.Publish("c"); err != ErrPublisherClosed {
+		t.Fatalf("expected ErrPublisherClosed, got %v", err)
+	}
+}
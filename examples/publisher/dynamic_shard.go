@@ -0,0 +1,127 @@
+package main
+
+import (
+	"errors"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// ErrNoShards is returned when publishing to a DynamicShardPublisher without any shards
+var ErrNoShards = errors.New("no shards available")
+
+// DynamicShardPublisher routes messages to one of several shard Publishers using consistent hashing
+// Shards can be added and removed at runtime, which only moves the keys owned by the affected shard
+type DynamicShardPublisher struct {
+	keyFn    func(msg string) string
+	replicas int
+
+	mu     sync.RWMutex
+	shards map[string]Publisher
+
+	// ring holds the sorted hashes of all virtual nodes, owners maps them back to their shard
+	ring   []uint32
+	owners map[uint32]string
+}
+
+// NewDynamicShardPublisher creates a DynamicShardPublisher routing by the key derived by keyFn
+// Each shard is placed on the hash ring `replicas` times (virtual nodes) to even out the distribution
+func NewDynamicShardPublisher(keyFn func(msg string) string, replicas int) *DynamicShardPublisher {
+	if replicas < 1 {
+		replicas = 1
+	}
+
+	return &DynamicShardPublisher{
+		keyFn:    keyFn,
+		replicas: replicas,
+		shards:   map[string]Publisher{},
+		owners:   map[uint32]string{},
+	}
+}
+
+// AddShard adds a shard under the given name, replacing any existing shard with the same name
+func (d *DynamicShardPublisher) AddShard(name string, p Publisher) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.shards[name]; !ok {
+		for i := 0; i < d.replicas; i++ {
+			h := ringHash(name + "#" + strconv.Itoa(i))
+			d.ring = append(d.ring, h)
+			d.owners[h] = name
+		}
+		sort.Slice(d.ring, func(i, j int) bool { return d.ring[i] < d.ring[j] })
+	}
+
+	d.shards[name] = p
+}
+
+// RemoveShard removes the shard with the given name, its keys are spread among the remaining shards
+func (d *DynamicShardPublisher) RemoveShard(name string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.shards[name]; !ok {
+		return
+	}
+	delete(d.shards, name)
+
+	ring := d.ring[:0]
+	for _, h := range d.ring {
+		if d.owners[h] == name {
+			delete(d.owners, h)
+			continue
+		}
+		ring = append(ring, h)
+	}
+	d.ring = ring
+}
+
+// Publish sends the message to the shard owning its key
+func (d *DynamicShardPublisher) Publish(msg string) error {
+	d.mu.RLock()
+	p, err := d.shardFor(d.keyFn(msg))
+	d.mu.RUnlock()
+
+	if err != nil {
+		return err
+	}
+
+	return p.Publish(msg)
+}
+
+// ShardFor returns the name of the shard owning the given key
+func (d *DynamicShardPublisher) ShardFor(key string) (string, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if len(d.ring) == 0 {
+		return "", ErrNoShards
+	}
+	return d.owners[d.ring[d.ringIndex(key)]], nil
+}
+
+func (d *DynamicShardPublisher) shardFor(key string) (Publisher, error) {
+	if len(d.ring) == 0 {
+		return nil, ErrNoShards
+	}
+	return d.shards[d.owners[d.ring[d.ringIndex(key)]]], nil
+}
+
+// ringIndex finds the first virtual node clockwise from the key's hash
+func (d *DynamicShardPublisher) ringIndex(key string) int {
+	h := ringHash(key)
+
+	i := sort.Search(len(d.ring), func(i int) bool { return d.ring[i] >= h })
+	if i == len(d.ring) {
+		i = 0
+	}
+	return i
+}
+
+func ringHash(s string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(s))
+	return h.Sum32()
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDynamicShardPublisherRebalance(t *testing.T) {
+	d := NewDynamicShardPublisher(func(msg string) string { return msg }, 100)
+	for i := 0; i < 3; i++ {
+		d.AddShard(fmt.Sprintf("shard-%d", i), &collectingPublisher{})
+	}
+
+	keys := 1000
+	before := map[string]string{}
+	for i := 0; i < keys; i++ {
+		k := fmt.Sprintf("key-%d", i)
+		before[k], _ = d.ShardFor(k)
+	}
+
+	d.AddShard("shard-3", &collectingPublisher{})
+
+	moved := 0
+	for k, shard := range before {
+		now, _ := d.ShardFor(k)
+		if now == shard {
+			continue
+		}
+
+		// keys only ever move to the new shard
+		if now != "shard-3" {
+			t.Fatalf("expected %s to stay on %s or move to shard-3, got %s", k, shard, now)
+		}
+		moved++
+	}
+
+	// ideally a quarter of the keys move to the new shard
+	if moved == 0 || moved > keys*2/5 {
+		t.Fatalf("expected a small fraction of keys to move, %d of %d moved", moved, keys)
+	}
+}
+
+func TestDynamicShardPublisherPublish(t *testing.T) {
+	d := NewDynamicShardPublisher(func(msg string) string { return msg }, 10)
+
+	if err := d.Publish("msg"); err != ErrNoShards {
+		t.Fatalf("expected ErrNoShards, got %v", err)
+	}
+
+	a, b := &collectingPublisher{}, &collectingPublisher{}
+	d.AddShard("a", a)
+	d.AddShard("b", b)
+
+	shard, _ := d.ShardFor("msg")
+	d.Publish("msg")
+
+	owner, other := a, b
+	if shard == "b" {
+		owner, other = b, a
+	}
+	if len(owner.Messages()) != 1 || len(other.Messages()) != 0 {
+		t.Fatalf("expected the message to go to %s only", shard)
+	}
+
+	// removing the owning shard hands its keys to the remaining one
+	d.RemoveShard(shard)
+	d.Publish("msg")
+	if len(other.Messages()) != 1 {
+		t.Fatal("expected the message to go to the remaining shard")
+	}
+}
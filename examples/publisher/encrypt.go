@@ -0,0 +1,107 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+)
+
+// ErrMessageTooShort is returned when decrypting a message which can't possibly hold a nonce and ciphertext
+var ErrMessageTooShort = errors.New("encrypted message is too short")
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Encrypt encrypts msg with AES-GCM using the given key (16, 24 or 32 bytes)
+// A random nonce is prepended to the ciphertext, and the result is base64 encoded
+func Encrypt(key []byte, msg string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	return encryptGCM(gcm, msg)
+}
+
+// Decrypt reverses Encrypt, it fails if the message was tampered with
+func Decrypt(key []byte, msg string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	return decryptGCM(gcm, msg)
+}
+
+func encryptGCM(gcm cipher.AEAD, msg string) (string, error) {
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	// appending to the nonce results in nonce+ciphertext
+	sealed := gcm.Seal(nonce, nonce, []byte(msg), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func decryptGCM(gcm cipher.AEAD, msg string) (string, error) {
+	bs, err := base64.StdEncoding.DecodeString(msg)
+	if err != nil {
+		return "", err
+	}
+
+	if len(bs) < gcm.NonceSize() {
+		return "", ErrMessageTooShort
+	}
+
+	plain, err := gcm.Open(nil, bs[:gcm.NonceSize()], bs[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+// EncryptPublisher encrypts each message (see Encrypt) before sending it to the given Publisher
+// An invalid key results in every publish failing
+func EncryptPublisher(p Publisher, key []byte) Publisher {
+	gcm, keyErr := newGCM(key)
+
+	return &MockPublisher{
+		PublishFn: func(msg string) error {
+			if keyErr != nil {
+				return keyErr
+			}
+
+			emsg, err := encryptGCM(gcm, msg)
+			if err != nil {
+				return err
+			}
+			return p.Publish(emsg)
+		},
+	}
+}
+
+// DecryptPublisher decrypts each message (see Decrypt) before sending it to the given Publisher
+// Messages which fail to decrypt are not sent
+func DecryptPublisher(p Publisher, key []byte) Publisher {
+	gcm, keyErr := newGCM(key)
+
+	return &MockPublisher{
+		PublishFn: func(msg string) error {
+			if keyErr != nil {
+				return keyErr
+			}
+
+			dmsg, err := decryptGCM(gcm, msg)
+			if err != nil {
+				return err
+			}
+			return p.Publish(dmsg)
+		},
+	}
+}
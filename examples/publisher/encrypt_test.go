@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/base64"
+	"reflect"
+	"testing"
+)
+
+func TestEncryptPublisherRoundTrip(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+
+	p := &collectingPublisher{}
+	ep := EncryptPublisher(DecryptPublisher(p, key), key)
+
+	if err := ep.Publish("secret message"); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if msgs := p.Messages(); !reflect.DeepEqual(msgs, []string{"secret message"}) {
+		t.Fatalf("expected the message to round-trip, got %q", msgs)
+	}
+}
+
+func TestDecryptTampered(t *testing.T) {
+	key := []byte("0123456789abcdef")
+
+	emsg, err := Encrypt(key, "secret message")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if emsg == "secret message" {
+		t.Fatal("expected the message to be encrypted")
+	}
+
+	// flip a bit in the ciphertext
+	bs, _ := base64.StdEncoding.DecodeString(emsg)
+	bs[len(bs)-1] ^= 1
+	tampered := base64.StdEncoding.EncodeToString(bs)
+
+	p := &collectingPublisher{}
+	if err := DecryptPublisher(p, key).Publish(tampered); err == nil {
+		t.Fatal("expected tampered message to fail to decrypt")
+	}
+	if msgs := p.Messages(); len(msgs) != 0 {
+		t.Fatalf("expected nothing to be published, got %q", msgs)
+	}
+
+	if _, err := Decrypt(key, base64.StdEncoding.EncodeToString([]byte("short"))); err != ErrMessageTooShort {
+		t.Fatalf("expected ErrMessageTooShort, got %v", err)
+	}
+}
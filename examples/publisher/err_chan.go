@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// PublishError reports a message which failed to publish
+type PublishError struct {
+	Msg string
+	Err error
+}
+
+func (e PublishError) Error() string {
+	return "failed to publish msg " + e.Msg + ": " + e.Err.Error()
+}
+
+func (e PublishError) Unwrap() error {
+	return e.Err
+}
+
+// ErrQueueFull is returned when a message can't be queued because the queue is already full
+var ErrQueueFull = errors.New("publish queue is full")
+
+// ErrChanPublisher queues messages and publishes them to the given Publisher in order from a single background worker
+// Publish returns nil once the message is queued, failures are reported on the returned channel instead
+// When the queue already holds queueSize messages Publish fails right away with ErrQueueFull rather than blocking
+// When the channel is full the oldest error is dropped to make room, so the worker never blocks on it
+// Closing the returned Publisher publishes any queued messages and then closes the channel
+func ErrChanPublisher(p Publisher, queueSize, bufferSize int) (Publisher, <-chan PublishError) {
+	if queueSize < 1 {
+		queueSize = 1
+	}
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	errc := make(chan PublishError, bufferSize)
+	msgs := make(chan string, queueSize)
+	done := make(chan struct{})
+
+	// mu guards against sending on msgs after it has been closed
+	var mu sync.RWMutex
+	closed := false
+
+	// report is only called by the worker, so it's the only one adding errors
+	report := func(perr PublishError) {
+		for {
+			select {
+			case errc <- perr:
+				return
+			default:
+			}
+
+			// full, drop the oldest error (unless someone beat us to reading it)
+			select {
+			case <-errc:
+			default:
+			}
+		}
+	}
+
+	go func() {
+		defer close(done)
+		defer close(errc)
+
+		for msg := range msgs {
+			if err := p.Publish(msg); err != nil {
+				report(PublishError{Msg: msg, Err: err})
+			}
+		}
+	}()
+
+	return &MockPublisherCloser{
+		PublishFn: func(msg string) error {
+			mu.RLock()
+			defer mu.RUnlock()
+
+			if closed {
+				return ErrPublisherClosed
+			}
+
+			select {
+			case msgs <- msg:
+				return nil
+			default:
+				return ErrQueueFull
+			}
+		},
+		CloseFn: func(ctx context.Context) error {
+			mu.Lock()
+			if closed {
+				mu.Unlock()
+				return ErrPublisherClosed
+			}
+			closed = true
+			close(msgs)
+			mu.Unlock()
+
+			// wait for the worker to publish the remaining messages
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}, errc
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestErrChanPublisher(t *testing.T) {
+	errUnavailable := errors.New("unavailable")
+	p, errc := ErrChanPublisher(&MockPublisher{
+		PublishFn: func(msg string) error {
+			if msg == "bad" {
+				return errUnavailable
+			}
+			return nil
+		},
+	}, 10, 10)
+
+	if err := p.Publish("good"); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := p.Publish("bad"); err != nil {
+		t.Fatalf("expected failures to be reported on the channel, got %v", err)
+	}
+
+	select {
+	case perr := <-errc:
+		if perr.Msg != "bad" || !errors.Is(perr, errUnavailable) {
+			t.Fatalf("expected the failed message and its error, got %v", perr)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the failure")
+	}
+
+	select {
+	case perr := <-errc:
+		t.Fatalf("expected a single failure, got %v", perr)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestErrChanPublisherQueueFull(t *testing.T) {
+	started, release := make(chan string), make(chan struct{})
+	p, errc := ErrChanPublisher(gatedPublisher(started, release), 1, 1)
+
+	// a is being published, b waits in the queue, c doesn't fit
+	p.Publish("a")
+	<-started
+	if err := p.Publish("b"); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := p.Publish("c"); err != ErrQueueFull {
+		t.Fatalf("expected ErrQueueFull, got %v", err)
+	}
+
+	close(release)
+	if msg := <-started; msg != "b" {
+		t.Fatalf("expected b to be published next, got %q", msg)
+	}
+
+	if err := p.(PublisherCloser).Close(context.Background()); err != nil {
+		t.Fatalf("unexpected error on close %v", err)
+	}
+	if _, ok := <-errc; ok {
+		t.Fatal("expected the error channel to be closed")
+	}
+	if err := p.Publish("d"); err != ErrPublisherClosed {
+		t.Fatalf("expected ErrPublisherClosed, got %v", err)
+	}
+}
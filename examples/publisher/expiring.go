@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type bufferedMsg struct {
+	msg string
+	at  time.Time
+}
+
+// ExpiringPublisher buffers messages until it is flushed (or closed)
+// At flush time messages are sent to the given Publisher, unless they've been waiting for longer than ttl,
+// in which case they're sent to the dead-letter Publisher instead, annotated with how long they waited
+// Messages not sent by the time the flush context is done stay buffered,
+// unless it's the Close context, in which case they're dropped and ErrMessagesDropped is returned
+func ExpiringPublisher(p, dlq Publisher, ttl time.Duration) Publisher {
+	var mu sync.Mutex
+	buf := []bufferedMsg{}
+	closed := false
+
+	// flushMu serializes flushes, so messages go out in the order they were published
+	var flushMu sync.Mutex
+
+	flush := func(ctx context.Context, progress ProgressFunc) error {
+		flushMu.Lock()
+		defer flushMu.Unlock()
+
+		mu.Lock()
+		msgs := buf
+		buf = []bufferedMsg{}
+		mu.Unlock()
+
+		progress(len(msgs))
+
+		errs := []error{}
+		for i, m := range msgs {
+			if err := ctx.Err(); err != nil {
+				// put back whatever we didn't get to, ahead of anything newer
+				mu.Lock()
+				buf = append(append([]bufferedMsg{}, msgs[i:]...), buf...)
+				mu.Unlock()
+
+				errs = append(errs, err)
+				break
+			}
+
+			var err error
+
+			// stale messages are captured rather than delivered late
+			if elapsed := time.Since(m.at); elapsed > ttl {
+				err = dlq.Publish(fmt.Sprintf("[expired after %s] %s", elapsed, m.msg))
+			} else {
+				err = p.Publish(m.msg)
+			}
+
+			if err != nil {
+				errs = append(errs, err)
+			}
+			progress(len(msgs) - i - 1)
+		}
+
+		return errors.Join(errs...)
+	}
+
+	return &MockBufferedPublisher{
+		PublishFn: func(msg string) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if closed {
+				return ErrPublisherClosed
+			}
+
+			buf = append(buf, bufferedMsg{msg: msg, at: time.Now()})
+			return nil
+		},
+		FlushFn: func(ctx context.Context, progress ProgressFunc) error {
+			return flush(ctx, progress)
+		},
+		CloseFn: func(ctx context.Context) error {
+			mu.Lock()
+			if closed {
+				mu.Unlock()
+				return ErrPublisherClosed
+			}
+			closed = true
+			mu.Unlock()
+
+			err := flush(ctx, func(remaining int) {})
+
+			// nothing will flush again, so whatever was put back is lost
+			mu.Lock()
+			defer mu.Unlock()
+
+			if n := len(buf); n > 0 {
+				buf = []bufferedMsg{}
+				err = fmt.Errorf("%w: %d messages: %w", ErrMessagesDropped, n, err)
+			}
+			return err
+		},
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpiringPublisher(t *testing.T) {
+	p, dlq := &collectingPublisher{}, &collectingPublisher{}
+	ep := ExpiringPublisher(p, dlq, 20*time.Millisecond).(PublisherFlusher)
+
+	ep.Publish("stale")
+	time.Sleep(30 * time.Millisecond)
+	ep.Publish("fresh")
+
+	if err := ep.Flush(context.Background(), nil); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if msgs := p.Messages(); !reflect.DeepEqual(msgs, []string{"fresh"}) {
+		t.Fatalf("expected only the fresh message to be published, got %q", msgs)
+	}
+
+	dead := dlq.Messages()
+	if len(dead) != 1 || !strings.HasPrefix(dead[0], "[expired after ") || !strings.HasSuffix(dead[0], "] stale") {
+		t.Fatalf("expected the stale message to be dead-lettered with its age, got %q", dead)
+	}
+
+	age := strings.TrimSuffix(strings.TrimPrefix(dead[0], "[expired after "), "] stale")
+	elapsed, err := time.ParseDuration(age)
+	if err != nil || elapsed < 20*time.Millisecond {
+		t.Fatalf("expected an elapsed time past the ttl, got %q", age)
+	}
+}
+
+func TestExpiringPublisherClose(t *testing.T) {
+	p, dlq := &collectingPublisher{}, &collectingPublisher{}
+	ep := ExpiringPublisher(p, dlq, time.Hour).(PublisherCloser)
+
+	ep.Publish("a")
+	if err := ep.Close(context.Background()); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if msgs := p.Messages(); !reflect.DeepEqual(msgs, []string{"a"}) {
+		t.Fatalf("expected [a] to be flushed on close, got %q", msgs)
+	}
+
+	if err := ep.Publish("b"); err != ErrPublisherClosed {
+		t.Fatalf("expected ErrPublisherClosed, got %v", err)
+	}
+	if err := ep.Close(context.Background()); err != ErrPublisherClosed {
+		t.Fatalf("expected ErrPublisherClosed on a second close, got %v", err)
+	}
+}
+
+func TestExpiringPublisherSerialFlushes(t *testing.T) {
+	started, release := make(chan string), make(chan struct{})
+	ep := ExpiringPublisher(gatedPublisher(started, release), nil, time.Hour).(PublisherFlusher)
+
+	ep.Publish("a")
+	go ep.Flush(context.Background(), nil)
+	<-started
+
+	// a second flush mustn't overtake the first
+	ep.Publish("b")
+	go ep.Flush(context.Background(), nil)
+
+	time.Sleep(20 * time.Millisecond)
+	select {
+	case msg := <-started:
+		t.Fatalf("expected the second flush to wait for the first, but it published %q", msg)
+	default:
+	}
+
+	release <- struct{}{}
+	if msg := <-started; msg != "b" {
+		t.Fatalf("expected b to be published next, got %q", msg)
+	}
+	release <- struct{}{}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ExternalTx stages messages to be published only once an external transaction (e.g a database one) succeeds
+type ExternalTx struct {
+	p Publisher
+
+	mu     sync.Mutex
+	staged []string
+}
+
+// ExternalTxPublisher creates an ExternalTx publishing to the given Publisher
+func ExternalTxPublisher(p Publisher) *ExternalTx {
+	return &ExternalTx{p: p}
+}
+
+// Stage buffers a message until the next CommitWith
+func (tx *ExternalTx) Stage(msg string) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	tx.staged = append(tx.staged, msg)
+}
+
+// CommitWith runs fn, and only if it succeeds publishes the staged messages in order
+// If fn fails the staged messages are discarded and its error is returned
+// Either way, staging starts over afterwards
+func (tx *ExternalTx) CommitWith(fn func() error) error {
+	tx.mu.Lock()
+	msgs := tx.staged
+	tx.staged = nil
+	tx.mu.Unlock()
+
+	if err := fn(); err != nil {
+		return fmt.Errorf("external transaction failed, discarded %d staged messages: %w", len(msgs), err)
+	}
+
+	errs := []error{}
+	for _, msg := range msgs {
+		if err := tx.p.Publish(msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestExternalTx(t *testing.T) {
+	p := &collectingPublisher{}
+	tx := ExternalTxPublisher(p)
+
+	errTx := errors.New("constraint violation")
+	tx.Stage("discarded")
+	if err := tx.CommitWith(func() error { return errTx }); !errors.Is(err, errTx) {
+		t.Fatalf("expected the transaction error, got %v", err)
+	}
+	if msgs := p.Messages(); len(msgs) != 0 {
+		t.Fatalf("expected staged messages to be discarded, got %q", msgs)
+	}
+
+	tx.Stage("a")
+	tx.Stage("b")
+	if err := tx.CommitWith(func() error { return nil }); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if msgs := p.Messages(); !reflect.DeepEqual(msgs, []string{"a", "b"}) {
+		t.Fatalf("expected staged messages to be published in order, got %q", msgs)
+	}
+}
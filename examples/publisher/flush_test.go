@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFlushProgress(t *testing.T) {
+	tcs := []struct {
+		name      string
+		newFn     func(p Publisher) Publisher
+		published int
+	}{
+		{"batch", func(p Publisher) Publisher { return BatchPublisher(p, 10) }, 1},
+		{"json batch", func(p Publisher) Publisher { return JSONArrayBatchPublisher(p, 10) }, 1},
+		{"smart batch", func(p Publisher) Publisher { return SmartBatchPublisher(p, BatchConfig{MaxCount: 10}) }, 1},
+		{"compact", func(p Publisher) Publisher {
+			return CompactPublisher(p, func(msg string) string { return msg }, time.Hour)
+		}, 3},
+		{"expiring", func(p Publisher) Publisher { return ExpiringPublisher(p, p, time.Hour) }, 3},
+		{"delayed", func(p Publisher) Publisher {
+			dp, _ := DelayedPublisher(p, time.Hour)
+			return dp
+		}, 3},
+		{"serial", func(p Publisher) Publisher {
+			// slow down the worker so the flush has something to wait for
+			sp, _ := SerialPublisher(&MockPublisher{
+				PublishFn: func(msg string) error {
+					time.Sleep(5 * time.Millisecond)
+					return p.Publish(msg)
+				},
+			}, 10)
+			return sp
+		}, 3},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &collectingPublisher{}
+			fp := tc.newFn(p).(PublisherFlusher)
+			defer fp.(PublisherCloser).Close(context.Background())
+
+			for _, msg := range []string{"a", "b", "c"} {
+				if err := fp.Publish(msg); err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+			}
+
+			progress := []int{}
+			if err := fp.Flush(context.Background(), func(remaining int) {
+				progress = append(progress, remaining)
+			}); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			if len(progress) == 0 || progress[0] > 3 || progress[len(progress)-1] != 0 {
+				t.Fatalf("expected progress to count down to 0, got %v", progress)
+			}
+			for i := 1; i < len(progress); i++ {
+				if progress[i] >= progress[i-1] {
+					t.Fatalf("expected progress to keep decreasing, got %v", progress)
+				}
+			}
+
+			if n := len(p.Messages()); n != tc.published {
+				t.Fatalf("expected %d messages to be published by the flush, got %d", tc.published, n)
+			}
+		})
+	}
+}
+
+func TestFlushContextDone(t *testing.T) {
+	tcs := []struct {
+		name  string
+		newFn func(p Publisher) Publisher
+	}{
+		{"compact", func(p Publisher) Publisher {
+			return CompactPublisher(p, func(msg string) string { return msg }, time.Hour)
+		}},
+		{"expiring", func(p Publisher) Publisher { return ExpiringPublisher(p, p, time.Hour) }},
+		{"delayed", func(p Publisher) Publisher {
+			dp, _ := DelayedPublisher(p, time.Hour)
+			return dp
+		}},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &collectingPublisher{}
+			fp := tc.newFn(p).(PublisherFlusher)
+			defer fp.(PublisherCloser).Close(context.Background())
+
+			fp.Publish("a")
+			fp.Publish("b")
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			if err := fp.Flush(ctx, nil); !errors.Is(err, context.Canceled) {
+				t.Fatalf("expected context.Canceled, got %v", err)
+			}
+			if msgs := p.Messages(); len(msgs) != 0 {
+				t.Fatalf("expected nothing to be published, got %q", msgs)
+			}
+
+			// nothing was lost, the next flush picks up where the last one stopped
+			if err := fp.Flush(context.Background(), nil); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if msgs := p.Messages(); !reflect.DeepEqual(msgs, []string{"a", "b"}) {
+				t.Fatalf("expected [a b], got %q", msgs)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"sync/atomic"
+)
+
+// HandoffPublisher sends messages to the old Publisher until cutover returns true, and to the new one from then on
+// cutover is checked on every publish until it first returns true, after which the switch is permanent
+func HandoffPublisher(oldp, newp Publisher, cutover func() bool) Publisher {
+	var switched int32
+
+	return &MockPublisher{
+		PublishFn: func(msg string) error {
+			if atomic.LoadInt32(&switched) == 1 {
+				return newp.Publish(msg)
+			}
+
+			if cutover() {
+				atomic.StoreInt32(&switched, 1)
+				return newp.Publish(msg)
+			}
+
+			return oldp.Publish(msg)
+		},
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHandoffPublisher(t *testing.T) {
+	oldp, newp := &collectingPublisher{}, &collectingPublisher{}
+
+	checks := 0
+	cut := false
+	hp := HandoffPublisher(oldp, newp, func() bool {
+		checks++
+		return cut
+	})
+
+	hp.Publish("before-1")
+	hp.Publish("before-2")
+
+	cut = true
+	hp.Publish("after-1")
+
+	// flipping back doesn't undo the switch, and cutover is no longer consulted
+	cut = false
+	hp.Publish("after-2")
+
+	if msgs := oldp.Messages(); !reflect.DeepEqual(msgs, []string{"before-1", "before-2"}) {
+		t.Fatalf("expected messages before the cutover on the old publisher, got %q", msgs)
+	}
+	if msgs := newp.Messages(); !reflect.DeepEqual(msgs, []string{"after-1", "after-2"}) {
+		t.Fatalf("expected messages after the cutover on the new publisher, got %q", msgs)
+	}
+	if checks != 3 {
+		t.Fatalf("expected cutover to be checked until it first returned true, got %d checks", checks)
+	}
+}
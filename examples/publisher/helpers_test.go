@@ -0,0 +1,38 @@
+package main
+
+import (
+	"sync"
+)
+
+// collectingPublisher records every message published to it, failing with err if set
+type collectingPublisher struct {
+	mu   sync.Mutex
+	msgs []string
+	err  error
+}
+
+func (c *collectingPublisher) Publish(msg string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil {
+		return c.err
+	}
+	c.msgs = append(c.msgs, msg)
+	return nil
+}
+
+func (c *collectingPublisher) setErr(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.err = err
+}
+
+func (c *collectingPublisher) Messages() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	msgs := make([]string, len(c.msgs))
+	copy(msgs, c.msgs)
+	return msgs
+}
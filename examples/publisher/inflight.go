@@ -0,0 +1,60 @@
+package main
+
+import (
+	"sort"
+	"sync"
+)
+
+// InflightTracker is a Publisher which keeps track of the messages currently being published
+type InflightTracker struct {
+	p Publisher
+
+	mu       sync.Mutex
+	nextID   uint64
+	inflight map[uint64]string
+}
+
+// InflightTrackingPublisher tracks messages from the moment they're handed to the given Publisher until it returns
+// This allows detecting stuck publishes, or knowing what was in flight when recovering from a crash
+func InflightTrackingPublisher(p Publisher) *InflightTracker {
+	return &InflightTracker{
+		p:        p,
+		inflight: map[uint64]string{},
+	}
+}
+
+// Publish publishes the message, tracking it for as long as it's in flight
+func (t *InflightTracker) Publish(msg string) error {
+	t.mu.Lock()
+	id := t.nextID
+	t.nextID++
+	t.inflight[id] = msg
+	t.mu.Unlock()
+
+	// stop tracking regardless of the outcome
+	defer func() {
+		t.mu.Lock()
+		delete(t.inflight, id)
+		t.mu.Unlock()
+	}()
+
+	return t.p.Publish(msg)
+}
+
+// Inflight returns the messages currently being published, oldest first
+func (t *InflightTracker) Inflight() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ids := make([]uint64, 0, len(t.inflight))
+	for id := range t.inflight {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	msgs := make([]string, len(ids))
+	for i, id := range ids {
+		msgs[i] = t.inflight[id]
+	}
+	return msgs
+}
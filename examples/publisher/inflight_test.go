@@ -0,0 +1,49 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestInflightTrackingPublisher(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	tp := InflightTrackingPublisher(&MockPublisher{
+		PublishFn: func(msg string) error {
+			if msg == "blocked" {
+				close(started)
+				<-release
+			}
+			return nil
+		},
+	})
+
+	tp.Publish("quick")
+	if msgs := tp.Inflight(); len(msgs) != 0 {
+		t.Fatalf("expected completed publishes not to be tracked, got %q", msgs)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		tp.Publish("blocked")
+	}()
+	<-started
+
+	if msgs := tp.Inflight(); !reflect.DeepEqual(msgs, []string{"blocked"}) {
+		t.Fatalf("expected the blocked publish to be in flight, got %q", msgs)
+	}
+
+	close(release)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for publish")
+	}
+
+	if msgs := tp.Inflight(); len(msgs) != 0 {
+		t.Fatalf("expected the publish to be removed once completed, got %q", msgs)
+	}
+}
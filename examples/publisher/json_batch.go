@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// JSONArrayBatchPublisher batches messages together and sends them out as a single JSON array
+// Unlike BatchPublisher, consumers receive well-formed JSON regardless of the message contents
+// Flushing or closing it sends out a partial batch right away
+func JSONArrayBatchPublisher(p Publisher, batchSize int) Publisher {
+	var mu sync.Mutex
+	closed := false
+
+	// hold our batched msgs somewhere
+	msgs := []string{}
+
+	flush := func() error {
+		if len(msgs) == 0 {
+			return nil
+		}
+
+		// each message is encoded as a JSON string element
+		bs, err := json.Marshal(msgs)
+		if err != nil {
+			return err
+		}
+
+		// start a fresh batch
+		msgs = []string{}
+
+		return p.Publish(string(bs))
+	}
+
+	return &MockBufferedPublisher{
+		PublishFn: func(msg string) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if closed {
+				return ErrPublisherClosed
+			}
+
+			msgs = append(msgs, msg)
+
+			// still waiting for batch buffer to fill up
+			if len(msgs) < batchSize {
+				return nil
+			}
+
+			return flush()
+		},
+		FlushFn: func(ctx context.Context, progress ProgressFunc) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			progress(len(msgs))
+			err := flush()
+			progress(0)
+
+			return err
+		},
+		CloseFn: func(ctx context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if closed {
+				return ErrPublisherClosed
+			}
+			closed = true
+
+			return flush()
+		},
+	}
+}
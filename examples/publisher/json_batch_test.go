@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestJSONArrayBatchPublisher(t *testing.T) {
+	p := &collectingPublisher{}
+	bp := JSONArrayBatchPublisher(p, 2)
+
+	in := []string{`a,b`, `say "hi"`, `{"k":1}`, "new\nline"}
+	for _, msg := range in {
+		if err := bp.Publish(msg); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+
+	batches := p.Messages()
+	if len(batches) != 2 {
+		t.Fatalf("expected 2 batches, got %d: %v", len(batches), batches)
+	}
+
+	var out []string
+	for _, b := range batches {
+		var msgs []string
+		if err := json.Unmarshal([]byte(b), &msgs); err != nil {
+			t.Fatalf("expected batch to be a JSON array, got %q: %v", b, err)
+		}
+		if len(msgs) != 2 {
+			t.Fatalf("expected 2 messages per batch, got %v", msgs)
+		}
+		out = append(out, msgs...)
+	}
+
+	if !reflect.DeepEqual(out, in) {
+		t.Fatalf("expected %q, got %q", in, out)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// latency buckets start at 1µs and grow by 5% each, which covers up to ~20 minutes in 430 buckets
+	latencyBucketMin    = time.Microsecond
+	latencyBucketGrowth = 1.05
+	latencyBucketCount  = 430
+)
+
+// LatencyProfile estimates publish latency percentiles using a fixed-size logarithmic histogram
+// Individual samples aren't stored, estimates are accurate to within ~5%
+type LatencyProfile struct {
+	mu      sync.Mutex
+	buckets [latencyBucketCount]uint64
+	count   uint64
+}
+
+func (lp *LatencyProfile) observe(d time.Duration) {
+	i := 0
+	if d > latencyBucketMin {
+		i = int(math.Ceil(math.Log(float64(d)/float64(latencyBucketMin)) / math.Log(latencyBucketGrowth)))
+	}
+	if i >= latencyBucketCount {
+		i = latencyBucketCount - 1
+	}
+
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+
+	lp.buckets[i]++
+	lp.count++
+}
+
+// Percentile returns the estimated latency below which the fraction q (0-1) of publishes fall
+func (lp *LatencyProfile) Percentile(q float64) time.Duration {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+
+	if lp.count == 0 {
+		return 0
+	}
+
+	// the rank of the sample we're looking for
+	rank := uint64(math.Ceil(q * float64(lp.count)))
+	if rank < 1 {
+		rank = 1
+	}
+
+	var seen uint64
+	for i, n := range lp.buckets {
+		seen += n
+		if seen >= rank {
+			// report the bucket's upper bound
+			return time.Duration(float64(latencyBucketMin) * math.Pow(latencyBucketGrowth, float64(i)))
+		}
+	}
+
+	return time.Duration(float64(latencyBucketMin) * math.Pow(latencyBucketGrowth, latencyBucketCount-1))
+}
+
+// P50 returns the estimated median publish latency
+func (lp *LatencyProfile) P50() time.Duration { return lp.Percentile(0.50) }
+
+// P95 returns the estimated 95th percentile publish latency
+func (lp *LatencyProfile) P95() time.Duration { return lp.Percentile(0.95) }
+
+// P99 returns the estimated 99th percentile publish latency
+func (lp *LatencyProfile) P99() time.Duration { return lp.Percentile(0.99) }
+
+// Count returns the number of publishes observed
+func (lp *LatencyProfile) Count() uint64 {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+	return lp.count
+}
+
+// LatencyProfilePublisher measures how long each publish to the given Publisher takes
+// Failed publishes are measured as well
+func LatencyProfilePublisher(p Publisher) (Publisher, *LatencyProfile) {
+	lp := &LatencyProfile{}
+
+	return &MockPublisher{
+		PublishFn: func(msg string) error {
+			start := time.Now()
+			err := p.Publish(msg)
+			lp.observe(time.Since(start))
+			return err
+		},
+	}, lp
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestLatencyProfilePercentiles(t *testing.T) {
+	lp := &LatencyProfile{}
+
+	// 1ms, 2ms, ..., 100ms
+	for i := 1; i <= 100; i++ {
+		lp.observe(time.Duration(i) * time.Millisecond)
+	}
+
+	tcs := []struct {
+		name     string
+		got      time.Duration
+		expected time.Duration
+	}{
+		{"p50", lp.P50(), 50 * time.Millisecond},
+		{"p95", lp.P95(), 95 * time.Millisecond},
+		{"p99", lp.P99(), 99 * time.Millisecond},
+	}
+
+	for _, tc := range tcs {
+		if diff := math.Abs(float64(tc.got-tc.expected)) / float64(tc.expected); diff > 0.05 {
+			t.Fatalf("%s: expected ~%s, got %s", tc.name, tc.expected, tc.got)
+		}
+	}
+
+	if lp.Count() != 100 {
+		t.Fatalf("expected 100 observations, got %d", lp.Count())
+	}
+}
+
+func TestLatencyProfilePublisher(t *testing.T) {
+	delay := 5 * time.Millisecond
+	p, lp := LatencyProfilePublisher(&MockPublisher{
+		PublishFn: func(msg string) error {
+			time.Sleep(delay)
+			return nil
+		},
+	})
+
+	for i := 0; i < 10; i++ {
+		p.Publish("msg")
+	}
+
+	if lp.Count() != 10 {
+		t.Fatalf("expected 10 observations, got %d", lp.Count())
+	}
+
+	// sleeping can overshoot but never undershoot, allowing for bucket precision
+	if p50 := lp.P50(); float64(p50) < float64(delay)*0.95 {
+		t.Fatalf("expected p50 of at least %s, got %s", delay, p50)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"sync"
+)
+
+// LazyPublisher defers creating the underlying Publisher (e.g connecting to a broker) until the first publish
+// Once created successfully it's reused for all later publishes,
+// if creating it fails the error is returned and creation is attempted again on the next publish
+func LazyPublisher(factory func() (Publisher, error)) Publisher {
+	var mu sync.Mutex
+	var p Publisher
+
+	get := func() (Publisher, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if p != nil {
+			return p, nil
+		}
+
+		np, err := factory()
+		if err != nil {
+			return nil, err
+		}
+		p = np
+
+		return p, nil
+	}
+
+	return &MockPublisher{
+		PublishFn: func(msg string) error {
+			p, err := get()
+			if err != nil {
+				return err
+			}
+			return p.Publish(msg)
+		},
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestLazyPublisher(t *testing.T) {
+	p := &collectingPublisher{}
+
+	var mu sync.Mutex
+	calls := 0
+	lp := LazyPublisher(func() (Publisher, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		return p, nil
+	})
+
+	if calls != 0 {
+		t.Fatal("expected the factory not to run before the first publish")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := lp.Publish("msg"); err != nil {
+				t.Errorf("unexpected error %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if calls != 1 {
+		t.Fatalf("expected the factory to run exactly once, got %d", calls)
+	}
+	if n := len(p.Messages()); n != 10 {
+		t.Fatalf("expected 10 messages, got %d", n)
+	}
+}
+
+func TestLazyPublisherRetriesFailedFactory(t *testing.T) {
+	p := &collectingPublisher{}
+	errConnect := errors.New("connection refused")
+
+	calls := 0
+	lp := LazyPublisher(func() (Publisher, error) {
+		calls++
+		if calls == 1 {
+			return nil, errConnect
+		}
+		return p, nil
+	})
+
+	if err := lp.Publish("a"); err != errConnect {
+		t.Fatalf("expected the factory error, got %v", err)
+	}
+	if err := lp.Publish("b"); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	lp.Publish("c")
+
+	if calls != 2 {
+		t.Fatalf("expected the factory to be retried once, got %d calls", calls)
+	}
+	if msgs := p.Messages(); !reflect.DeepEqual(msgs, []string{"b", "c"}) {
+		t.Fatalf("expected [b c], got %q", msgs)
+	}
+}
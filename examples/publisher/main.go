@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -101,6 +104,63 @@ func (p *MockPublisher) Publish(msg string) error {
 	return p.PublishFn(msg)
 }
 
+// ProgressFunc is called with the number of messages still waiting to be flushed
+type ProgressFunc func(remaining int)
+
+// PublisherFlusher is a Publisher which buffers messages and can be asked to flush them,
+// reporting its progress along the way
+type PublisherFlusher interface {
+	Publisher
+	Flush(ctx context.Context, progress ProgressFunc) error
+}
+
+// PublisherCloser is a Publisher which holds resources that need to be released when done
+type PublisherCloser interface {
+	Publisher
+	Close(ctx context.Context) error
+}
+
+// MockPublisherCloser is a mockable PublisherCloser
+type MockPublisherCloser struct {
+	PublishFn func(msg string) error
+	CloseFn   func(ctx context.Context) error
+}
+
+// Publish calls the underlying Publish method
+func (p *MockPublisherCloser) Publish(msg string) error {
+	return p.PublishFn(msg)
+}
+
+// Close calls the underlying Close method
+func (p *MockPublisherCloser) Close(ctx context.Context) error {
+	return p.CloseFn(ctx)
+}
+
+// MockBufferedPublisher is a mockable Publisher which is both a PublisherFlusher and a PublisherCloser
+type MockBufferedPublisher struct {
+	PublishFn func(msg string) error
+	FlushFn   func(ctx context.Context, progress ProgressFunc) error
+	CloseFn   func(ctx context.Context) error
+}
+
+// Publish calls the underlying Publish method
+func (p *MockBufferedPublisher) Publish(msg string) error {
+	return p.PublishFn(msg)
+}
+
+// Flush calls the underlying Flush method
+func (p *MockBufferedPublisher) Flush(ctx context.Context, progress ProgressFunc) error {
+	if progress == nil {
+		progress = func(remaining int) {}
+	}
+	return p.FlushFn(ctx, progress)
+}
+
+// Close calls the underlying Close method
+func (p *MockBufferedPublisher) Close(ctx context.Context) error {
+	return p.CloseFn(ctx)
+}
+
 // TransformFunc is a function that changes a message and returns the changed version
 type TransformFunc func(msg string) string
 
@@ -115,8 +175,10 @@ func TransformPublisher(p Publisher, tfn TransformFunc) Publisher {
 }
 
 // MultiPublisher wraps all given Publishers into one Publisher
+// Closing it closes all the given Publishers which are PublisherClosers concurrently,
+// any of them which haven't finished closing by the context deadline are reported in the returned error
 func MultiPublisher(ps ...Publisher) Publisher {
-	return &MockPublisher{
+	return &MockPublisherCloser{
 		PublishFn: func(msg string) error {
 			// iterate over all publishers and send to each in turn
 			for _, p := range ps {
@@ -128,24 +190,92 @@ func MultiPublisher(ps ...Publisher) Publisher {
 			}
 			return nil
 		},
+		CloseFn: func(ctx context.Context) error {
+			type closeResult struct {
+				i   int
+				err error
+			}
+
+			// buffered so slow publishers can still finish closing after we've given up on them
+			results := make(chan closeResult, len(ps))
+			pending := map[int]bool{}
+
+			for i, p := range ps {
+				pc, ok := p.(PublisherCloser)
+				if !ok {
+					continue
+				}
+				pending[i] = true
+
+				go func(i int, pc PublisherCloser) {
+					results <- closeResult{i, pc.Close(ctx)}
+				}(i, pc)
+			}
+
+			errs := []error{}
+			for len(pending) > 0 {
+				select {
+				case r := <-results:
+					delete(pending, r.i)
+					if r.err != nil {
+						errs = append(errs, fmt.Errorf("publisher %d: %w", r.i, r.err))
+					}
+
+				case <-ctx.Done():
+					// report whoever didn't make it in time
+					for i := range ps {
+						if pending[i] {
+							errs = append(errs, fmt.Errorf("publisher %d: close timed out: %w", i, ctx.Err()))
+						}
+					}
+					return errors.Join(errs...)
+				}
+			}
+
+			return errors.Join(errs...)
+		},
 	}
 }
 
 // BatchPublisher batches messages together before sending them out
+// Each batch holds batchSize messages, a new one is started once it's sent
+// Flushing or closing it sends out a partial batch right away, once closed it rejects further messages
 func BatchPublisher(p Publisher, batchSize int) Publisher {
+	var mu sync.Mutex
+	closed := false
+
 	// hold our batched msgs somewhere
 	msgs := []string{}
 
-	return &MockPublisher{
+	// flush sends out whatever has been batched so far, mu must be held
+	flush := func() error {
+		if len(msgs) == 0 {
+			return nil
+		}
+
+		// there's multiple ways to batch the messages
+		// in this case we'll just concatenate them
+		batchMsg := strings.Join(msgs, ",")
+
+		// start over so the next batch doesn't repeat this one
+		msgs = []string{}
+		return p.Publish(batchMsg)
+	}
+
+	return &MockBufferedPublisher{
 		PublishFn: func(msg string) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if closed {
+				return ErrPublisherClosed
+			}
+
 			msgs = append(msgs, msg)
 
 			// if enough messages have been batched, we can send them out
 			if len(msgs) == batchSize {
-				// there's multiple ways to batch the messages
-				// in this case we'll just concatenate them
-				batchMsg := strings.Join(msgs, ",")
-				return p.Publish(batchMsg)
+				return flush()
 			}
 
 			// Note: It's also possible to flush the batch publisher after some pre-defined time duration
@@ -154,5 +284,30 @@ func BatchPublisher(p Publisher, batchSize int) Publisher {
 			// still waiting for batch buffer to fill up
 			return nil
 		},
+		FlushFn: func(ctx context.Context, progress ProgressFunc) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			progress(len(msgs))
+			err := flush()
+			progress(0)
+
+			return err
+		},
+		CloseFn: func(ctx context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if closed {
+				return ErrPublisherClosed
+			}
+			closed = true
+
+			return flush()
+		},
 	}
 }
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// MonotonicTimestampPublisher prefixes each message with a nanosecond timestamp
+// which is guaranteed to be strictly greater than the one before it
+// time.Now() can return the same value for messages published in quick succession,
+// in which case the timestamp is bumped by a nanosecond
+func MonotonicTimestampPublisher(p Publisher) Publisher {
+	var mu sync.Mutex
+	var last int64
+
+	return &MockPublisher{
+		PublishFn: func(msg string) error {
+			// hold the lock while publishing so messages also arrive in timestamp order
+			mu.Lock()
+			defer mu.Unlock()
+
+			ts := time.Now().UnixNano()
+			if ts <= last {
+				ts = last + 1
+			}
+			last = ts
+
+			return p.Publish(fmt.Sprintf("[%d] %s", ts, msg))
+		},
+	}
+}
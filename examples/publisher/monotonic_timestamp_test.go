@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestMonotonicTimestampPublisher(t *testing.T) {
+	p := &collectingPublisher{}
+	mp := MonotonicTimestampPublisher(p)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 1000; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			mp.Publish(fmt.Sprintf("msg-%d", i))
+		}(i)
+	}
+	wg.Wait()
+
+	msgs := p.Messages()
+	if len(msgs) != 1000 {
+		t.Fatalf("expected 1000 messages, got %d", len(msgs))
+	}
+
+	var last int64
+	for _, msg := range msgs {
+		var ts int64
+		if _, err := fmt.Sscanf(msg, "[%d]", &ts); err != nil {
+			t.Fatalf("failed to parse timestamp from %q: %v", msg, err)
+		}
+		if ts <= last {
+			t.Fatalf("expected strictly increasing timestamps, got %d after %d", ts, last)
+		}
+		last = ts
+	}
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"encoding/json"
+)
+
+// MultiFormatPublisher sends each message as-is to the text Publisher,
+// and wrapped in a JSON object (`{"message": msg}`) to the JSON Publisher
+func MultiFormatPublisher(text, jsonp Publisher) Publisher {
+	return MultiPublisher(
+		text,
+		TransformPublisher(jsonp, func(msg string) string {
+			// marshaling a plain string can't fail
+			bs, _ := json.Marshal(struct {
+				Message string `json:"message"`
+			}{msg})
+			return string(bs)
+		}),
+	)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMultiFormatPublisher(t *testing.T) {
+	text, jsonp := &collectingPublisher{}, &collectingPublisher{}
+	p := MultiFormatPublisher(text, jsonp)
+
+	msg := `say "hi"`
+	if err := p.Publish(msg); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if msgs := text.Messages(); !reflect.DeepEqual(msgs, []string{msg}) {
+		t.Fatalf("expected the plain message, got %q", msgs)
+	}
+
+	msgs := jsonp.Messages()
+	if len(msgs) != 1 {
+		t.Fatalf("expected 1 JSON message, got %q", msgs)
+	}
+
+	var obj struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal([]byte(msgs[0]), &obj); err != nil {
+		t.Fatalf("expected a JSON object, got %q: %v", msgs[0], err)
+	}
+	if obj.Message != msg {
+		t.Fatalf("expected %q, got %q", msg, obj.Message)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMultiPublisherCloseTimeout(t *testing.T) {
+	fastClosed := make(chan struct{})
+	fast := &MockPublisherCloser{
+		PublishFn: func(msg string) error { return nil },
+		CloseFn: func(ctx context.Context) error {
+			close(fastClosed)
+			return nil
+		},
+	}
+
+	release := make(chan struct{})
+	defer close(release)
+	slow := &MockPublisherCloser{
+		PublishFn: func(msg string) error { return nil },
+		CloseFn: func(ctx context.Context) error {
+			<-release
+			return nil
+		},
+	}
+
+	plain := &MockPublisher{PublishFn: func(msg string) error { return nil }}
+
+	mp := MultiPublisher(fast, slow, plain).(PublisherCloser)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := mp.Close(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "publisher 1: close timed out") {
+		t.Fatalf("expected the slow publisher to be named, got %v", err)
+	}
+	if strings.Contains(err.Error(), "publisher 0") || strings.Contains(err.Error(), "publisher 2") {
+		t.Fatalf("expected only the slow publisher to be named, got %v", err)
+	}
+
+	select {
+	case <-fastClosed:
+	default:
+		t.Fatal("expected the fast publisher to be closed")
+	}
+}
+
+func TestMultiPublisherCloseReachesWrapped(t *testing.T) {
+	p := &collectingPublisher{}
+	cp := CompactPublisher(p, func(msg string) string { return msg }, time.Hour)
+	cp.Publish("a")
+
+	// wrappers which don't hold resources themselves aren't closers
+	if _, ok := TransformPublisher(cp, strings.ToUpper).(PublisherCloser); ok {
+		t.Fatal("expected TransformPublisher not to be a PublisherCloser")
+	}
+
+	if err := MultiPublisher(cp).(PublisherCloser).Close(context.Background()); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if msgs := p.Messages(); len(msgs) != 1 || msgs[0] != "a" {
+		t.Fatalf("expected close to flush the compacted message, got %q", msgs)
+	}
+}
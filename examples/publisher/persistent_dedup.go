@@ -0,0 +1,66 @@
+package main
+
+import (
+	"sync"
+)
+
+// DedupStore keeps track of which message keys have already been published
+// Implementations backed by e.g Redis or SQL allow de-duplicating across process restarts
+type DedupStore interface {
+	Seen(key string) (bool, error)
+	Mark(key string) error
+}
+
+// MemoryDedupStore is an in-memory DedupStore, mostly useful for tests
+type MemoryDedupStore struct {
+	mu   sync.Mutex
+	keys map[string]bool
+}
+
+// NewMemoryDedupStore creates a new empty MemoryDedupStore
+func NewMemoryDedupStore() *MemoryDedupStore {
+	return &MemoryDedupStore{
+		keys: map[string]bool{},
+	}
+}
+
+// Seen reports whether the key was marked
+func (s *MemoryDedupStore) Seen(key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.keys[key], nil
+}
+
+// Mark marks the key as seen
+func (s *MemoryDedupStore) Mark(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[key] = true
+	return nil
+}
+
+// PersistentDedupPublisher drops messages whose key (as derived by keyFn) was already marked in the given DedupStore
+// Keys are only marked once their message was published successfully
+func PersistentDedupPublisher(p Publisher, store DedupStore, keyFn func(msg string) string) Publisher {
+	return &MockPublisher{
+		PublishFn: func(msg string) error {
+			key := keyFn(msg)
+
+			seen, err := store.Seen(key)
+			if err != nil {
+				return err
+			}
+
+			// already published, nothing to do
+			if seen {
+				return nil
+			}
+
+			if err := p.Publish(msg); err != nil {
+				return err
+			}
+
+			return store.Mark(key)
+		},
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPersistentDedupPublisher(t *testing.T) {
+	p := &collectingPublisher{}
+	store := NewMemoryDedupStore()
+	keyFn := func(msg string) string { return strings.SplitN(msg, ":", 2)[0] }
+
+	// marked by a previous run
+	store.Mark("1")
+
+	dp := PersistentDedupPublisher(p, store, keyFn)
+	for _, msg := range []string{"1:a", "2:b", "2:c", "3:d"} {
+		if err := dp.Publish(msg); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+
+	if msgs := p.Messages(); !reflect.DeepEqual(msgs, []string{"2:b", "3:d"}) {
+		t.Fatalf("expected marked keys to be dropped, got %q", msgs)
+	}
+}
+
+func TestPersistentDedupPublisherFailure(t *testing.T) {
+	p := &collectingPublisher{}
+	store := NewMemoryDedupStore()
+	dp := PersistentDedupPublisher(p, store, func(msg string) string { return msg })
+
+	p.setErr(errors.New("unavailable"))
+	if err := dp.Publish("a"); err == nil {
+		t.Fatal("expected publish to fail")
+	}
+
+	// failed messages aren't marked, so they can be retried
+	if seen, _ := store.Seen("a"); seen {
+		t.Fatal("expected a failed message not to be marked")
+	}
+
+	p.setErr(nil)
+	if err := dp.Publish("a"); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if seen, _ := store.Seen("a"); !seen {
+		t.Fatal("expected a published message to be marked")
+	}
+}
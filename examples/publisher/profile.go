@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// ProfilePublisher sends every message to the given Publisher,
+// and additionally sends every Nth message along with some profiling metadata to the given sink
+func ProfilePublisher(p Publisher, sink Publisher, everyN int) Publisher {
+	var count uint64
+
+	return &MockPublisher{
+		PublishFn: func(msg string) error {
+			if err := p.Publish(msg); err != nil {
+				return err
+			}
+
+			if everyN <= 0 || atomic.AddUint64(&count, 1)%uint64(everyN) != 0 {
+				return nil
+			}
+
+			// sampling is best-effort, a failing sink shouldn't fail the actual publish
+			sink.Publish(fmt.Sprintf("ts=%d size=%d msg=%s", time.Now().UnixNano(), len(msg), msg))
+
+			return nil
+		},
+	}
+}
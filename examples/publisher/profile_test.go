@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestProfilePublisher(t *testing.T) {
+	p, sink := &collectingPublisher{}, &collectingPublisher{}
+	pp := ProfilePublisher(p, sink, 3)
+
+	for i := 1; i <= 10; i++ {
+		if err := pp.Publish(fmt.Sprintf("msg-%d", i)); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+
+	if got := len(p.Messages()); got != 10 {
+		t.Fatalf("expected all 10 messages to be published, got %d", got)
+	}
+
+	sampled := sink.Messages()
+	expected := []string{"msg-3", "msg-6", "msg-9"}
+	if len(sampled) != len(expected) {
+		t.Fatalf("expected %d sampled messages, got %d: %v", len(expected), len(sampled), sampled)
+	}
+	for i, msg := range expected {
+		if !strings.HasSuffix(sampled[i], "msg="+msg) {
+			t.Fatalf("expected sample %d to be for %s, got %q", i, msg, sampled[i])
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// QuorumPublisher sends each message to all given Publishers concurrently
+// Publishing succeeds if at least `required` of them succeed, otherwise all their errors are returned joined together
+func QuorumPublisher(ps []Publisher, required int) Publisher {
+	return &MockPublisher{
+		PublishFn: func(msg string) error {
+			errs := make([]error, len(ps))
+
+			var wg sync.WaitGroup
+			for i, p := range ps {
+				wg.Add(1)
+				go func(i int, p Publisher) {
+					defer wg.Done()
+					errs[i] = p.Publish(msg)
+				}(i, p)
+			}
+			wg.Wait()
+
+			succeeded := 0
+			failures := []error{}
+			for i, err := range errs {
+				if err != nil {
+					failures = append(failures, fmt.Errorf("publisher %d: %w", i, err))
+					continue
+				}
+				succeeded++
+			}
+
+			if succeeded >= required {
+				return nil
+			}
+
+			return fmt.Errorf("only %d of %d publishers succeeded, %d required: %w", succeeded, len(ps), required, errors.Join(failures...))
+		},
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestQuorumPublisher(t *testing.T) {
+	errUnavailable := errors.New("unavailable")
+
+	tcs := []struct {
+		name      string
+		failing   int
+		expectErr bool
+	}{
+		{"2 of 3 succeed", 1, false},
+		{"1 of 3 succeed", 2, true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ps := make([]Publisher, 3)
+			for i := range ps {
+				cp := &collectingPublisher{}
+				if i < tc.failing {
+					cp.setErr(errUnavailable)
+				}
+				ps[i] = cp
+			}
+
+			err := QuorumPublisher(ps, 2).Publish("msg")
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if !tc.expectErr {
+				return
+			}
+
+			if !errors.Is(err, errUnavailable) {
+				t.Fatalf("expected the publisher errors to be joined, got %v", err)
+			}
+			if !strings.Contains(err.Error(), "only 1 of 3 publishers succeeded") {
+				t.Fatalf("expected the error to report the success count, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrMaxRedeliveries is passed to the drop callback when a message was nacked too many times
+var ErrMaxRedeliveries = errors.New("message exceeded max redeliveries")
+
+// ErrDeliverySettled is returned when nacking a delivery which was already acked or nacked
+var ErrDeliverySettled = errors.New("delivery is already settled")
+
+// Delivery is a handle to a published message which the consumer can Ack or Nack
+type Delivery struct {
+	Msg string
+
+	mu      sync.Mutex
+	settled bool
+
+	// redelivers is fixed when the delivery is created, every redelivery gets its own Delivery
+	redelivers int
+
+	p               Publisher
+	maxRedeliveries int
+	onDrop          func(msg string, err error)
+}
+
+// Ack marks the message as handled, after which it will no longer be redelivered
+func (d *Delivery) Ack() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.settled = true
+}
+
+// Nack redelivers the message and returns a new Delivery for the redelivered copy,
+// unless it has already been redelivered the maximum number of times,
+// in which case it's dropped and handed to the drop callback instead
+// If the redelivery fails the delivery stays unsettled, so it can be nacked again
+func (d *Delivery) Nack() (*Delivery, error) {
+	d.mu.Lock()
+	if d.settled {
+		d.mu.Unlock()
+		return nil, ErrDeliverySettled
+	}
+	d.settled = true
+	d.mu.Unlock()
+
+	if d.redelivers >= d.maxRedeliveries {
+		if d.onDrop != nil {
+			d.onDrop(d.Msg, ErrMaxRedeliveries)
+		}
+		return nil, ErrMaxRedeliveries
+	}
+
+	// publish without holding the lock, a slow downstream shouldn't block Ack or Redeliveries
+	if err := d.p.Publish(d.Msg); err != nil {
+		d.mu.Lock()
+		d.settled = false
+		d.mu.Unlock()
+		return nil, err
+	}
+
+	return &Delivery{
+		Msg:             d.Msg,
+		redelivers:      d.redelivers + 1,
+		p:               d.p,
+		maxRedeliveries: d.maxRedeliveries,
+		onDrop:          d.onDrop,
+	}, nil
+}
+
+// Redeliveries returns the number of times the message was redelivered
+func (d *Delivery) Redeliveries() int {
+	return d.redelivers
+}
+
+// DeliveryPublisher is a Publisher which can also hand out a Delivery for each published message
+type DeliveryPublisher interface {
+	Publisher
+	PublishDelivery(msg string) (*Delivery, error)
+}
+
+type redeliveryPublisher struct {
+	p               Publisher
+	maxRedeliveries int
+	onDrop          func(msg string, err error)
+}
+
+// RedeliveryPublisher publishes messages to the given Publisher and returns a Delivery handle for each
+// Nacking a delivery redelivers its message and hands out a new Delivery for it, up to maxRedeliveries times,
+// after which the message is dropped and passed to onDrop
+func RedeliveryPublisher(p Publisher, maxRedeliveries int, onDrop func(msg string, err error)) DeliveryPublisher {
+	return &redeliveryPublisher{
+		p:               p,
+		maxRedeliveries: maxRedeliveries,
+		onDrop:          onDrop,
+	}
+}
+
+// Publish publishes the message without tracking its delivery
+func (r *redeliveryPublisher) Publish(msg string) error {
+	return r.p.Publish(msg)
+}
+
+// PublishDelivery publishes the message and returns a handle which can be used to Ack or Nack it
+func (r *redeliveryPublisher) PublishDelivery(msg string) (*Delivery, error) {
+	if err := r.p.Publish(msg); err != nil {
+		return nil, err
+	}
+
+	return &Delivery{
+		Msg:             msg,
+		p:               r.p,
+		maxRedeliveries: r.maxRedeliveries,
+		onDrop:          r.onDrop,
+	}, nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRedeliveryPublisherNack(t *testing.T) {
+	p := &collectingPublisher{}
+	rp := RedeliveryPublisher(p, 3, nil)
+
+	d, err := rp.PublishDelivery("msg")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	rd, err := d.Nack()
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if rd.Msg != "msg" || rd.Redeliveries() != 1 {
+		t.Fatalf("expected a redelivery of msg, got %q with %d redeliveries", rd.Msg, rd.Redeliveries())
+	}
+	if msgs := p.Messages(); !reflect.DeepEqual(msgs, []string{"msg", "msg"}) {
+		t.Fatalf("expected the message to be redelivered, got %q", msgs)
+	}
+
+	// the original delivery is settled, only the new one can be nacked
+	if _, err := d.Nack(); err != ErrDeliverySettled {
+		t.Fatalf("expected ErrDeliverySettled, got %v", err)
+	}
+
+	rd.Ack()
+	if _, err := rd.Nack(); err != ErrDeliverySettled {
+		t.Fatalf("expected ErrDeliverySettled after ack, got %v", err)
+	}
+}
+
+func TestRedeliveryPublisherMaxRedeliveries(t *testing.T) {
+	p := &collectingPublisher{}
+
+	var dropped []string
+	rp := RedeliveryPublisher(p, 1, func(msg string, err error) {
+		if err != ErrMaxRedeliveries {
+			t.Errorf("expected ErrMaxRedeliveries, got %v", err)
+		}
+		dropped = append(dropped, msg)
+	})
+
+	d, _ := rp.PublishDelivery("msg")
+	rd, err := d.Nack()
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if _, err := rd.Nack(); err != ErrMaxRedeliveries {
+		t.Fatalf("expected ErrMaxRedeliveries, got %v", err)
+	}
+	if !reflect.DeepEqual(dropped, []string{"msg"}) {
+		t.Fatalf("expected the message to be dropped, got %q", dropped)
+	}
+	if n := len(p.Messages()); n != 2 {
+		t.Fatalf("expected 2 deliveries, got %d", n)
+	}
+}
+
+func TestRedeliveryPublisherFailedNack(t *testing.T) {
+	p := &collectingPublisher{}
+	rp := RedeliveryPublisher(p, 3, nil)
+
+	d, _ := rp.PublishDelivery("msg")
+
+	errUnavailable := errors.New("unavailable")
+	p.setErr(errUnavailable)
+	if _, err := d.Nack(); err != errUnavailable {
+		t.Fatalf("expected the publish error, got %v", err)
+	}
+
+	// the failed redelivery can be retried
+	p.setErr(nil)
+	if _, err := d.Nack(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrPublisherClosed is returned when publishing to a Publisher that has already been closed
+var ErrPublisherClosed = errors.New("publisher is closed")
+
+// SerialPublisher funnels all messages through a single worker goroutine
+// so they are delivered to the given Publisher in strict FIFO order, even with concurrent callers.
+// The returned close function (or closing the Publisher) drains any queued messages, stops the worker
+// and returns the first error encountered while publishing
+// Flushing the returned Publisher waits for the currently queued messages to be published
+func SerialPublisher(p Publisher, buffer int) (Publisher, func() error) {
+	msgs := make(chan string, buffer)
+	done := make(chan struct{})
+
+	// mu guards against sending on msgs after it has been closed
+	var mu sync.RWMutex
+	closed := false
+
+	// the first error returned by the wrapped publisher
+	var firstErr error
+
+	// pending counts queued messages which haven't been published yet,
+	// the worker signals on published after each message so flushes can report progress
+	var pending int64
+	published := make(chan struct{}, 1)
+
+	go func() {
+		defer close(done)
+
+		// a single worker means messages go out in the exact order they were queued
+		for msg := range msgs {
+			if err := p.Publish(msg); err != nil && firstErr == nil {
+				firstErr = err
+			}
+
+			atomic.AddInt64(&pending, -1)
+			select {
+			case published <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	sp := &MockBufferedPublisher{
+		PublishFn: func(msg string) error {
+			mu.RLock()
+			defer mu.RUnlock()
+
+			if closed {
+				return ErrPublisherClosed
+			}
+
+			// blocks while the buffer is full
+			atomic.AddInt64(&pending, 1)
+			msgs <- msg
+			return nil
+		},
+		FlushFn: func(ctx context.Context, progress ProgressFunc) error {
+			last := -1
+			for {
+				remaining := int(atomic.LoadInt64(&pending))
+				if remaining != last {
+					progress(remaining)
+					last = remaining
+				}
+
+				if remaining == 0 {
+					return nil
+				}
+
+				select {
+				case <-published:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		},
+		CloseFn: func(ctx context.Context) error {
+			mu.Lock()
+			if closed {
+				mu.Unlock()
+				return ErrPublisherClosed
+			}
+			closed = true
+			close(msgs)
+			mu.Unlock()
+
+			// wait for the worker to drain the remaining messages
+			select {
+			case <-done:
+				return firstErr
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}
+
+	closeFn := func() error {
+		return sp.Close(context.Background())
+	}
+
+	return sp, closeFn
+}
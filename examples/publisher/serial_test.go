@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestSerialPublisherFIFO(t *testing.T) {
+	cp := &collectingPublisher{}
+	sp, closeFn := SerialPublisher(cp, 4)
+
+	// each caller publishes its own monotonic sequence, and holds a lock while doing so
+	// so the overall enqueue order is known as well
+	var mu sync.Mutex
+	enqueued := []string{}
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				msg := fmt.Sprintf("%d-%03d", w, i)
+
+				mu.Lock()
+				err := sp.Publish(msg)
+				enqueued = append(enqueued, msg)
+				mu.Unlock()
+
+				if err != nil {
+					t.Error(err)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if err := closeFn(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := cp.Messages()
+	if len(got) != len(enqueued) {
+		t.Fatalf("expected %d messages, got %d", len(enqueued), len(got))
+	}
+	for i := range got {
+		if got[i] != enqueued[i] {
+			t.Fatalf("message %d: expected %s, got %s", i, enqueued[i], got[i])
+		}
+	}
+
+	if err := sp.Publish("late"); err != ErrPublisherClosed {
+		t.Fatalf("expected ErrPublisherClosed, got %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"sync"
+)
+
+type flightCall struct {
+	wg  sync.WaitGroup
+	err error
+}
+
+// SingleFlightPublisher coalesces concurrent publishes of messages with the same key (as derived by keyFn)
+// Only the first caller actually publishes, the rest wait for it and share its result
+func SingleFlightPublisher(p Publisher, keyFn func(msg string) string) Publisher {
+	var mu sync.Mutex
+	calls := map[string]*flightCall{}
+
+	return &MockPublisher{
+		PublishFn: func(msg string) error {
+			key := keyFn(msg)
+
+			mu.Lock()
+			if c, ok := calls[key]; ok {
+				// someone is already publishing this, wait for them
+				mu.Unlock()
+				c.wg.Wait()
+				return c.err
+			}
+
+			c := &flightCall{}
+			c.wg.Add(1)
+			calls[key] = c
+			mu.Unlock()
+
+			c.err = p.Publish(msg)
+
+			mu.Lock()
+			delete(calls, key)
+			mu.Unlock()
+
+			c.wg.Done()
+
+			return c.err
+		},
+	}
+}
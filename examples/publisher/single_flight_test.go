@@ -0,0 +1,49 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSingleFlightPublisher(t *testing.T) {
+	var calls int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	p := SingleFlightPublisher(&MockPublisher{
+		PublishFn: func(msg string) error {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				close(started)
+			}
+			<-release
+			return nil
+		},
+	}, func(msg string) string { return msg })
+
+	var wg sync.WaitGroup
+	publish := func() {
+		defer wg.Done()
+		if err := p.Publish("same"); err != nil {
+			t.Errorf("unexpected error %v", err)
+		}
+	}
+
+	wg.Add(1)
+	go publish()
+	<-started
+
+	// the rest join the publish already in flight
+	for i := 0; i < 9; i++ {
+		wg.Add(1)
+		go publish()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Fatalf("expected 1 downstream call, got %d", n)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BatchEncoder combines a batch of messages into a single message
+type BatchEncoder func(msgs []string) (string, error)
+
+// CommaJoinEncoder joins messages with commas, same as BatchPublisher
+func CommaJoinEncoder(msgs []string) (string, error) {
+	return strings.Join(msgs, ","), nil
+}
+
+// NewlineEncoder joins messages with newlines, e.g for newline-delimited JSON
+func NewlineEncoder(msgs []string) (string, error) {
+	return strings.Join(msgs, "\n"), nil
+}
+
+// JSONArrayEncoder encodes messages as a JSON array of strings, same as JSONArrayBatchPublisher
+func JSONArrayEncoder(msgs []string) (string, error) {
+	bs, err := json.Marshal(msgs)
+	if err != nil {
+		return "", err
+	}
+	return string(bs), nil
+}
+
+// BatchConfig configures SmartBatchPublisher, zero values disable the respective trigger
+type BatchConfig struct {
+	// MaxCount flushes the batch once it holds this many messages
+	MaxCount int
+
+	// MaxBytes flushes the batch before it would grow beyond this many bytes (of raw messages)
+	MaxBytes int
+
+	// MaxWait flushes the batch once its oldest message has been waiting this long
+	MaxWait time.Duration
+
+	// Encode combines a batch into a single message, defaults to CommaJoinEncoder
+	Encode BatchEncoder
+}
+
+// SmartBatchPublisher batches messages together before sending them out,
+// flushing on whichever of the configured count, size or time triggers fires first
+// Errors from flushes triggered by MaxWait have no one to be reported to and are dropped
+// The returned Publisher can also be flushed or closed explicitly, closing flushes any remaining messages
+func SmartBatchPublisher(p Publisher, cfg BatchConfig) Publisher {
+	if cfg.Encode == nil {
+		cfg.Encode = CommaJoinEncoder
+	}
+
+	var mu sync.Mutex
+	msgs := []string{}
+	size := 0
+	closed := false
+
+	// gen identifies the current batch, so a timer left over from an earlier batch doesn't flush a later one
+	gen := 0
+	var timer *time.Timer
+
+	// flush sends out the current batch, mu must be held
+	flush := func() error {
+		if timer != nil {
+			timer.Stop()
+			timer = nil
+		}
+		gen++
+
+		if len(msgs) == 0 {
+			return nil
+		}
+
+		batch := msgs
+		msgs, size = []string{}, 0
+
+		batchMsg, err := cfg.Encode(batch)
+		if err != nil {
+			return err
+		}
+		return p.Publish(batchMsg)
+	}
+
+	return &MockBufferedPublisher{
+		PublishFn: func(msg string) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if closed {
+				return ErrPublisherClosed
+			}
+
+			// make room rather than letting the batch grow beyond MaxBytes
+			if cfg.MaxBytes > 0 && len(msgs) > 0 && size+len(msg) > cfg.MaxBytes {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+
+			msgs = append(msgs, msg)
+			size += len(msg)
+
+			if (cfg.MaxCount > 0 && len(msgs) >= cfg.MaxCount) || (cfg.MaxBytes > 0 && size >= cfg.MaxBytes) {
+				return flush()
+			}
+
+			// the first message of a batch starts the clock
+			if cfg.MaxWait > 0 && len(msgs) == 1 {
+				g := gen
+				timer = time.AfterFunc(cfg.MaxWait, func() {
+					mu.Lock()
+					defer mu.Unlock()
+
+					if g == gen {
+						flush()
+					}
+				})
+			}
+
+			return nil
+		},
+		FlushFn: func(ctx context.Context, progress ProgressFunc) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			progress(len(msgs))
+			err := flush()
+			progress(0)
+
+			return err
+		},
+		CloseFn: func(ctx context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if closed {
+				return ErrPublisherClosed
+			}
+			closed = true
+
+			return flush()
+		},
+	}
+}
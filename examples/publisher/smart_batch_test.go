@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSmartBatchPublisherMaxCount(t *testing.T) {
+	p := &collectingPublisher{}
+	bp := SmartBatchPublisher(p, BatchConfig{MaxCount: 2})
+
+	for _, msg := range []string{"a", "b", "c"} {
+		if err := bp.Publish(msg); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+
+	if got, want := p.Messages(), []string{"a,b"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %q before close, got %q", want, got)
+	}
+
+	if err := bp.(PublisherCloser).Close(context.Background()); err != nil {
+		t.Fatalf("unexpected error on close %v", err)
+	}
+	if got, want := p.Messages(), []string{"a,b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %q after close, got %q", want, got)
+	}
+}
+
+func TestSmartBatchPublisherMaxBytes(t *testing.T) {
+	p := &collectingPublisher{}
+	bp := SmartBatchPublisher(p, BatchConfig{MaxBytes: 5})
+
+	// "ccc" would push the batch beyond 5 bytes so "aa,b" goes out first,
+	// then "ccc" + "dd" reaches 5 bytes exactly and is flushed right away
+	for _, msg := range []string{"aa", "b", "ccc", "dd"} {
+		if err := bp.Publish(msg); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+
+	if got, want := p.Messages(), []string{"aa,b", "ccc,dd"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestSmartBatchPublisherMaxWait(t *testing.T) {
+	p := &collectingPublisher{}
+	bp := SmartBatchPublisher(p, BatchConfig{MaxWait: 20 * time.Millisecond})
+
+	for _, msg := range []string{"a", "b"} {
+		if err := bp.Publish(msg); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+
+	if got := p.Messages(); len(got) != 0 {
+		t.Fatalf("expected nothing to be published before MaxWait, got %q", got)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for len(p.Messages()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if got, want := p.Messages(), []string{"a,b"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %q after MaxWait, got %q", want, got)
+	}
+}
+
+func TestSmartBatchPublisherEncoder(t *testing.T) {
+	p := &collectingPublisher{}
+	bp := SmartBatchPublisher(p, BatchConfig{
+		MaxCount: 3,
+		Encode: func(msgs []string) (string, error) {
+			return strings.Join(msgs, "|"), nil
+		},
+	})
+
+	for _, msg := range []string{"a", "b", "c"} {
+		if err := bp.Publish(msg); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+
+	if got, want := p.Messages(), []string{"a|b|c"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// PublishCall is a single recorded call to a PublisherSpy
+type PublishCall struct {
+	Msg string
+	At  time.Time
+}
+
+// PublisherSpy is a Publisher which records every message published to it, to be asserted on in tests
+type PublisherSpy struct {
+	mu    sync.Mutex
+	calls []PublishCall
+}
+
+// SpyPublisher creates a new PublisherSpy
+func SpyPublisher() *PublisherSpy {
+	return &PublisherSpy{}
+}
+
+// Publish records the message and always succeeds
+func (s *PublisherSpy) Publish(msg string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls = append(s.calls, PublishCall{Msg: msg, At: time.Now()})
+	return nil
+}
+
+// Calls returns all recorded calls in the order they were made
+func (s *PublisherSpy) Calls() []PublishCall {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	calls := make([]PublishCall, len(s.calls))
+	copy(calls, s.calls)
+	return calls
+}
+
+// AssertPublished fails the test if msg was never published
+func (s *PublisherSpy) AssertPublished(t testing.TB, msg string) {
+	t.Helper()
+
+	if s.count(msg) == 0 {
+		t.Errorf("expected %q to be published, but it wasn't", msg)
+	}
+}
+
+// AssertPublishedTimes fails the test unless exactly n messages were published
+func (s *PublisherSpy) AssertPublishedTimes(t testing.TB, n int) {
+	t.Helper()
+
+	if got := len(s.Calls()); got != n {
+		t.Errorf("expected %d messages to be published, got %d", n, got)
+	}
+}
+
+// AssertNever fails the test if msg was ever published
+func (s *PublisherSpy) AssertNever(t testing.TB, msg string) {
+	t.Helper()
+
+	if n := s.count(msg); n > 0 {
+		t.Errorf("expected %q to never be published, but it was published %d times", msg, n)
+	}
+}
+
+func (s *PublisherSpy) count(msg string) int {
+	n := 0
+	for _, c := range s.Calls() {
+		if c.Msg == msg {
+			n++
+		}
+	}
+	return n
+}
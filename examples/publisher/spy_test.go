@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// fakeTB records failures instead of failing the actual test
+type fakeTB struct {
+	testing.TB
+	errors []string
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Errorf(format string, args ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func TestPublisherSpy(t *testing.T) {
+	s := SpyPublisher()
+	s.Publish("a")
+	s.Publish("a")
+	s.Publish("b")
+
+	tcs := []struct {
+		name       string
+		assert     func(tb testing.TB)
+		expectFail bool
+	}{
+		{"published passes", func(tb testing.TB) { s.AssertPublished(tb, "a") }, false},
+		{"published fails", func(tb testing.TB) { s.AssertPublished(tb, "c") }, true},
+		{"published times passes", func(tb testing.TB) { s.AssertPublishedTimes(tb, 3) }, false},
+		{"published times fails", func(tb testing.TB) { s.AssertPublishedTimes(tb, 2) }, true},
+		{"never passes", func(tb testing.TB) { s.AssertNever(tb, "c") }, false},
+		{"never fails", func(tb testing.TB) { s.AssertNever(tb, "b") }, true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			tb := &fakeTB{}
+			tc.assert(tb)
+
+			if failed := len(tb.errors) > 0; failed != tc.expectFail {
+				t.Fatalf("expected failure to be %t, got errors %q", tc.expectFail, tb.errors)
+			}
+		})
+	}
+
+	calls := s.Calls()
+	if len(calls) != 3 || calls[0].Msg != "a" || calls[2].Msg != "b" {
+		t.Fatalf("expected calls in order, got %v", calls)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// ThrottleErrorsPublisher logs errors from the given Publisher at most once per `every` interval
+// The first error opens an interval, errors occurring during it are counted instead of logged,
+// and once the interval ends a single summary line reports how many were suppressed
+// Errors are still returned to the caller as usual
+func ThrottleErrorsPublisher(p Publisher, logger *log.Logger, every time.Duration) Publisher {
+	var mu sync.Mutex
+	throttling := false
+	suppressed := 0
+	var lastErr error
+
+	// endInterval reports whatever was suppressed during the interval
+	endInterval := func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if suppressed > 0 {
+			logger.Printf("suppressed %d similar errors in the last %s, last error: %s", suppressed, every, lastErr)
+		}
+
+		throttling = false
+		suppressed = 0
+		lastErr = nil
+	}
+
+	return &MockPublisher{
+		PublishFn: func(msg string) error {
+			err := p.Publish(msg)
+			if err == nil {
+				return nil
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			// still within the quiet period, just keep count
+			if throttling {
+				suppressed++
+				lastErr = err
+				return err
+			}
+
+			logger.Printf("failed to publish msg: %s", err)
+
+			throttling = true
+			time.AfterFunc(every, endInterval)
+
+			return err
+		},
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer which is safe to write to from other goroutines
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestThrottleErrorsPublisher(t *testing.T) {
+	var buf syncBuffer
+	logger := log.New(&buf, "", 0)
+
+	cp := &collectingPublisher{err: errors.New("x")}
+	p := ThrottleErrorsPublisher(cp, logger, 50*time.Millisecond)
+
+	for i := 0; i < 10; i++ {
+		if err := p.Publish("msg"); err == nil {
+			t.Fatal("expected the error to be returned")
+		}
+	}
+
+	// let the interval end
+	time.Sleep(150 * time.Millisecond)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected an error line and a summary line, got %q", lines)
+	}
+
+	if lines[0] != "failed to publish msg: x" {
+		t.Errorf("unexpected error line %q", lines[0])
+	}
+
+	if !strings.HasPrefix(lines[1], "suppressed 9 similar errors") {
+		t.Errorf("unexpected summary line %q", lines[1])
+	}
+}
+
+func TestThrottleErrorsPublisherSuccess(t *testing.T) {
+	var buf syncBuffer
+	logger := log.New(&buf, "", 0)
+
+	cp := &collectingPublisher{}
+	p := ThrottleErrorsPublisher(cp, logger, 50*time.Millisecond)
+
+	if err := p.Publish("msg"); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.String() != "" {
+		t.Fatalf("expected nothing to be logged, got %q", buf.String())
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+// Tracer starts tracing spans, it's a minimal abstraction which can be backed by e.g OpenTelemetry
+type Tracer interface {
+	StartSpan(name string) Span
+}
+
+// Span is a single traced operation
+type Span interface {
+	SetAttribute(key string, value interface{})
+	SetError(err error)
+	End()
+}
+
+// NoopTracer is a Tracer which doesn't record anything
+type NoopTracer struct{}
+
+// StartSpan returns a Span which does nothing
+func (NoopTracer) StartSpan(name string) Span { return noopSpan{} }
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(key string, value interface{}) {}
+func (noopSpan) SetError(err error)                         {}
+func (noopSpan) End()                                       {}
+
+// TracedPublisher records a span around each publish to the given Publisher, including the message size and any error
+// A nil tracer defaults to NoopTracer
+func TracedPublisher(p Publisher, tracer Tracer) Publisher {
+	if tracer == nil {
+		tracer = NoopTracer{}
+	}
+
+	return &MockPublisher{
+		PublishFn: func(msg string) error {
+			span := tracer.StartSpan("publish")
+			defer span.End()
+
+			span.SetAttribute("message.size", len(msg))
+
+			err := p.Publish(msg)
+			if err != nil {
+				span.SetError(err)
+			}
+
+			return err
+		},
+	}
+}
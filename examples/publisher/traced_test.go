@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+type fakeSpan struct {
+	name  string
+	attrs map[string]interface{}
+	err   error
+	ended bool
+}
+
+func (s *fakeSpan) SetAttribute(key string, value interface{}) { s.attrs[key] = value }
+func (s *fakeSpan) SetError(err error)                         { s.err = err }
+func (s *fakeSpan) End()                                       { s.ended = true }
+
+type fakeTracer struct {
+	spans []*fakeSpan
+}
+
+func (t *fakeTracer) StartSpan(name string) Span {
+	s := &fakeSpan{name: name, attrs: map[string]interface{}{}}
+	t.spans = append(t.spans, s)
+	return s
+}
+
+func TestTracedPublisher(t *testing.T) {
+	errUnavailable := errors.New("unavailable")
+
+	p := &collectingPublisher{}
+	tracer := &fakeTracer{}
+	tp := TracedPublisher(p, tracer)
+
+	tp.Publish("hello")
+	p.setErr(errUnavailable)
+	tp.Publish("hi")
+
+	if len(tracer.spans) != 2 {
+		t.Fatalf("expected one span per publish, got %d", len(tracer.spans))
+	}
+
+	for i, expectedSize := range []int{5, 2} {
+		s := tracer.spans[i]
+		if s.name != "publish" || !s.ended {
+			t.Fatalf("expected an ended publish span, got %+v", s)
+		}
+		if s.attrs["message.size"] != expectedSize {
+			t.Fatalf("expected message.size %d, got %v", expectedSize, s.attrs["message.size"])
+		}
+	}
+
+	if tracer.spans[0].err != nil {
+		t.Fatalf("expected no error on the successful span, got %v", tracer.spans[0].err)
+	}
+	if tracer.spans[1].err != errUnavailable {
+		t.Fatalf("expected the error to be recorded, got %v", tracer.spans[1].err)
+	}
+}
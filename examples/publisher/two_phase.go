@@ -0,0 +1,40 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// PrepareFunc reserves a message ahead of publishing it and returns a token identifying the reservation
+type PrepareFunc func(msg string) (token string, err error)
+
+// TokenFunc acts on a previously prepared reservation, e.g to commit or roll it back
+type TokenFunc func(token string) error
+
+// TwoPhasePublisher prepares each message, publishes it and then commits the reservation
+// If publishing fails the reservation is rolled back instead of committed
+func TwoPhasePublisher(p Publisher, prepare PrepareFunc, commit, rollback TokenFunc) Publisher {
+	return &MockPublisher{
+		PublishFn: func(msg string) error {
+			// reserve the message first, nothing is published if this fails
+			token, err := prepare(msg)
+			if err != nil {
+				return fmt.Errorf("failed to prepare msg: %w", err)
+			}
+
+			if err := p.Publish(msg); err != nil {
+				// undo the reservation, but make sure to report both failures
+				if rerr := rollback(token); rerr != nil {
+					return errors.Join(err, fmt.Errorf("failed to rollback token %s: %w", token, rerr))
+				}
+				return err
+			}
+
+			if err := commit(token); err != nil {
+				return fmt.Errorf("failed to commit token %s: %w", token, err)
+			}
+
+			return nil
+		},
+	}
+}
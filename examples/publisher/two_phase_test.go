@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// twoPhaseRecorder records the calls made to the two-phase hooks
+type twoPhaseRecorder struct {
+	calls     []string
+	commitErr error
+}
+
+func (r *twoPhaseRecorder) prepare(msg string) (string, error) {
+	r.calls = append(r.calls, "prepare:"+msg)
+	return "token-" + msg, nil
+}
+
+func (r *twoPhaseRecorder) commit(token string) error {
+	r.calls = append(r.calls, "commit:"+token)
+	return r.commitErr
+}
+
+func (r *twoPhaseRecorder) rollback(token string) error {
+	r.calls = append(r.calls, "rollback:"+token)
+	return nil
+}
+
+func TestTwoPhasePublisher(t *testing.T) {
+	r := &twoPhaseRecorder{}
+	cp := &collectingPublisher{}
+
+	p := TwoPhasePublisher(cp, r.prepare, r.commit, r.rollback)
+	if err := p.Publish("a"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(cp.Messages(), []string{"a"}) {
+		t.Fatalf("expected message to be published, got %v", cp.Messages())
+	}
+
+	expected := []string{"prepare:a", "commit:token-a"}
+	if !reflect.DeepEqual(r.calls, expected) {
+		t.Fatalf("expected calls %v, got %v", expected, r.calls)
+	}
+}
+
+func TestTwoPhasePublisherRollback(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	r := &twoPhaseRecorder{}
+	cp := &collectingPublisher{err: errBoom}
+
+	p := TwoPhasePublisher(cp, r.prepare, r.commit, r.rollback)
+	if err := p.Publish("a"); !errors.Is(err, errBoom) {
+		t.Fatalf("expected publish error, got %v", err)
+	}
+
+	expected := []string{"prepare:a", "rollback:token-a"}
+	if !reflect.DeepEqual(r.calls, expected) {
+		t.Fatalf("expected calls %v, got %v", expected, r.calls)
+	}
+}
+
+func TestTwoPhasePublisherCommitFailure(t *testing.T) {
+	errCommit := errors.New("commit failed")
+
+	r := &twoPhaseRecorder{commitErr: errCommit}
+	cp := &collectingPublisher{}
+
+	p := TwoPhasePublisher(cp, r.prepare, r.commit, r.rollback)
+	if err := p.Publish("a"); !errors.Is(err, errCommit) {
+		t.Fatalf("expected commit error, got %v", err)
+	}
+
+	expected := []string{"prepare:a", "commit:token-a"}
+	if !reflect.DeepEqual(r.calls, expected) {
+		t.Fatalf("expected calls %v, got %v", expected, r.calls)
+	}
+}
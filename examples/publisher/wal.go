@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// RecoverablePublisher is a Publisher which can replay messages left over from a previous run
+type RecoverablePublisher interface {
+	Publisher
+	Recover() error
+}
+
+// walAck is the log record marking the oldest unacknowledged message as done with
+const walAck = "-"
+
+// walCompactAcks is how many acks the log accumulates before it's compacted
+const walCompactAcks = 1024
+
+// WALPublisher appends each message to a write-ahead log on disk before sending it to the given Publisher
+// Messages are acknowledged in the log once they've been published successfully, and the log is compacted occasionally
+// A message which fails to publish stays at the head of the log, and is retried ahead of any newer message,
+// so an error from Publish means the message was logged and will still be delivered
+// Once the head message has failed maxAttempts times it's sent to the dead-letter Publisher instead,
+// so it can't stall the stream for good, a non-positive maxAttempts retries forever
+// Acks aren't synced to disk, so an unclean shutdown may redeliver the most recently published messages
+// After an unclean shutdown, calling Recover replays any unpublished messages in their original order
+func WALPublisher(p, dlq Publisher, walPath string, maxAttempts int) (RecoverablePublisher, error) {
+	w := &walPublisher{
+		p:           p,
+		dlq:         dlq,
+		path:        walPath,
+		maxAttempts: maxAttempts,
+	}
+
+	// pick up whatever a previous run left behind, it will be replayed on Recover
+	pending, acked, torn, err := readWAL(walPath)
+	if err != nil {
+		return nil, err
+	}
+	w.pending = pending
+
+	// start from a clean log, otherwise new records would be appended onto a torn one and lost with it
+	if torn || acked > 0 {
+		if err := w.rewrite(); err != nil {
+			return nil, err
+		}
+	}
+
+	return w, nil
+}
+
+type walPublisher struct {
+	p, dlq      Publisher
+	path        string
+	maxAttempts int
+
+	// mu serializes publishes so the log order matches the delivery order
+	mu       sync.Mutex
+	pending  []string
+	acked    int
+	attempts int
+}
+
+func (w *walPublisher) Publish(msg string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.append(strconv.Quote(msg), true); err != nil {
+		return err
+	}
+	w.pending = append(w.pending, msg)
+
+	// earlier failures go out first, so delivery order always matches the log
+	return w.drain()
+}
+
+// Recover replays all unpublished messages in the log, stopping at the first failure
+func (w *walPublisher) Recover() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.drain()
+}
+
+// drain publishes pending messages in order, stopping at the first failure
+// Each message is acknowledged in the log once it's out, and the log is compacted once enough acks pile up
+func (w *walPublisher) drain() error {
+	for len(w.pending) > 0 {
+		if err := w.p.Publish(w.pending[0]); err != nil {
+			w.attempts++
+			if w.maxAttempts <= 0 || w.attempts < w.maxAttempts {
+				return err
+			}
+
+			// give up on the message rather than stalling everything behind it
+			if dlqErr := w.dlq.Publish(w.pending[0]); dlqErr != nil {
+				return errors.Join(err, dlqErr)
+			}
+		}
+
+		w.attempts = 0
+		w.pending = w.pending[1:]
+
+		if err := w.append(walAck, false); err != nil {
+			return err
+		}
+		w.acked++
+	}
+
+	// only compact once acks outweigh what's pending, so the cost is spread across many publishes
+	if w.acked >= walCompactAcks && w.acked >= len(w.pending) {
+		return w.rewrite()
+	}
+
+	return nil
+}
+
+// append adds a record to the end of the log, syncing it to disk if asked to
+func (w *walPublisher) append(record string, sync bool) error {
+	f, err := os.OpenFile(w.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(record + "\n"); err != nil {
+		return err
+	}
+
+	if !sync {
+		return nil
+	}
+	return f.Sync()
+}
+
+// rewrite replaces the log with the currently pending messages
+func (w *walPublisher) rewrite() error {
+	tmp := w.path + ".tmp"
+
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	// quote messages so they can safely contain newlines and arbitrary bytes
+	bw := bufio.NewWriter(f)
+	for _, msg := range w.pending {
+		bw.WriteString(strconv.Quote(msg) + "\n")
+	}
+
+	if err := bw.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	// renaming is atomic, so a crash can't leave a half-written log behind
+	if err := os.Rename(tmp, w.path); err != nil {
+		return err
+	}
+
+	w.acked = 0
+	return nil
+}
+
+// readWAL returns the unacknowledged messages in the log, along with how many acks it holds
+// and whether it ends in a torn record
+func readWAL(path string) (pending []string, acked int, torn bool, err error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, 0, false, nil
+	}
+	if err != nil {
+		return nil, 0, false, err
+	}
+	defer f.Close()
+
+	msgs := []string{}
+
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadString('\n')
+		if err == io.EOF {
+			// a record without its newline was never fully written
+			torn = line != ""
+			break
+		}
+		if err != nil {
+			return nil, 0, false, err
+		}
+		line = line[:len(line)-1]
+
+		if line == walAck {
+			acked++
+			continue
+		}
+
+		msg, err := strconv.Unquote(line)
+		if err != nil {
+			// a torn write at the end of the log means the message was never published
+			torn = true
+			break
+		}
+		msgs = append(msgs, msg)
+	}
+
+	if acked > len(msgs) {
+		acked = len(msgs)
+	}
+
+	return msgs[acked:], acked, torn, nil
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWALPublisherRecover(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wal")
+
+	// the downstream is unavailable, so messages pile up in the log
+	p := &collectingPublisher{}
+	p.setErr(errors.New("unavailable"))
+
+	wp, err := WALPublisher(p, nil, path, 0)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	in := []string{"a", "multi\nline", "\xff\xfe not utf-8"}
+	for _, msg := range in {
+		if err := wp.Publish(msg); err == nil {
+			t.Fatalf("expected publish of %q to fail", msg)
+		}
+	}
+
+	// simulate a crash by starting over with a fresh publisher on the same log
+	p = &collectingPublisher{}
+	wp, err = WALPublisher(p, nil, path, 0)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if err := wp.Recover(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if msgs := p.Messages(); !reflect.DeepEqual(msgs, in) {
+		t.Fatalf("expected %q to be replayed in order, got %q", in, msgs)
+	}
+
+	if pending, _, _, err := readWAL(path); err != nil || len(pending) != 0 {
+		t.Fatalf("expected an empty log after recovery, got %q, %v", pending, err)
+	}
+}
+
+func TestWALPublisherOrdering(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wal")
+
+	p := &collectingPublisher{}
+	wp, err := WALPublisher(p, nil, path, 0)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	p.setErr(errors.New("unavailable"))
+	if err := wp.Publish("a"); err == nil {
+		t.Fatal("expected publish to fail")
+	}
+
+	// the failed message must be delivered before any newer one
+	p.setErr(nil)
+	if err := wp.Publish("b"); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if msgs := p.Messages(); !reflect.DeepEqual(msgs, []string{"a", "b"}) {
+		t.Fatalf("expected [a b], got %q", msgs)
+	}
+}
+
+func TestWALPublisherTornWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wal")
+
+	if err := os.WriteFile(path, []byte("\"a\"\n\"b"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	pending, _, torn, err := readWAL(path)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if !torn || !reflect.DeepEqual(pending, []string{"a"}) {
+		t.Fatalf("expected the torn message to be dropped, got %q, torn %v", pending, torn)
+	}
+}
+
+func TestWALPublisherAppendAfterTornWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wal")
+
+	if err := os.WriteFile(path, []byte("\"a\"\n\"b"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &collectingPublisher{}
+	p.setErr(errors.New("unavailable"))
+
+	wp, err := WALPublisher(p, nil, path, 0)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// the new message must not be appended onto the torn one
+	if err := wp.Publish("c"); err == nil {
+		t.Fatal("expected publish to fail")
+	}
+
+	// simulate a crash before anything else touches the log
+	p = &collectingPublisher{}
+	wp, err = WALPublisher(p, nil, path, 0)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if err := wp.Recover(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if msgs := p.Messages(); !reflect.DeepEqual(msgs, []string{"a", "c"}) {
+		t.Fatalf("expected [a c] to be recovered, got %q", msgs)
+	}
+}
+
+func TestWALPublisherMaxAttempts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wal")
+
+	p, dlq := &collectingPublisher{}, &collectingPublisher{}
+	rejecting := &MockPublisher{
+		PublishFn: func(msg string) error {
+			if msg == "a" {
+				return errors.New("bad message")
+			}
+			return p.Publish(msg)
+		},
+	}
+
+	wp, err := WALPublisher(rejecting, dlq, path, 2)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if err := wp.Publish("a"); err == nil {
+		t.Fatal("expected publish to fail")
+	}
+
+	// the second attempt at a fails as well, so it's handed to the dead-letter publisher and b goes through
+	if err := wp.Publish("b"); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if msgs := dlq.Messages(); !reflect.DeepEqual(msgs, []string{"a"}) {
+		t.Fatalf("expected [a] to be dead-lettered, got %q", msgs)
+	}
+	if msgs := p.Messages(); !reflect.DeepEqual(msgs, []string{"b"}) {
+		t.Fatalf("expected [b] to be published, got %q", msgs)
+	}
+}
+
+func TestWALPublisherCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wal")
+
+	p := &collectingPublisher{}
+	wp, err := WALPublisher(p, nil, path, 0)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	for i := 0; i < walCompactAcks+10; i++ {
+		if err := wp.Publish("msg"); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+
+	pending, acked, _, err := readWAL(path)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(pending) != 0 {
+		t.Fatalf("expected nothing pending, got %d messages", len(pending))
+	}
+	if acked != 10 {
+		t.Fatalf("expected the log to be compacted down to 10 acks, got %d", acked)
+	}
+}